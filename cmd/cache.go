@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"sync"
+)
+
+// lookups caches, for the duration of a command, the lookups that are shared
+// by every service: the index of unit files, the set of enabled services and
+// service descriptions. Commands that change them call resetLookups.
+var lookups lookupCache
+
+type lookupCache struct {
+	mu           sync.Mutex
+	unitFiles    map[string]string
+	enabled      map[string]bool
+	descriptions map[string]string
+}
+
+// resetLookups forgets the cached lookups after services were converted,
+// enabled or disabled, or their unit files changed
+func resetLookups() {
+	lookups.mu.Lock()
+	defer lookups.mu.Unlock()
+
+	lookups.unitFiles = nil
+	lookups.enabled = nil
+	lookups.descriptions = nil
+}
+
+// getSystemdServiceFiles returns a map of all systemd service files, from
+// service name to the path of the unit file with the highest precedence.
+// The map is shared and must not be modified.
+func getSystemdServiceFiles() (map[string]string, error) {
+	lookups.mu.Lock()
+	defer lookups.mu.Unlock()
+
+	if lookups.unitFiles == nil {
+		lookups.unitFiles = scanSystemdServiceFiles()
+	}
+	return lookups.unitFiles, nil
+}
+
+// getEnabledServices returns a map of service names that are enabled in
+// OpenRC. The map is shared and must not be modified.
+func getEnabledServices() (map[string]bool, error) {
+	lookups.mu.Lock()
+	defer lookups.mu.Unlock()
+
+	if lookups.enabled == nil {
+		enabled, err := listEnabledServices()
+		if err != nil {
+			return nil, err
+		}
+		lookups.enabled = enabled
+	}
+	return lookups.enabled, nil
+}
+
+// getServiceDescription returns the description of a service from OpenRC or systemd
+func getServiceDescription(serviceName string) string {
+	lookups.mu.Lock()
+	description, ok := lookups.descriptions[serviceName]
+	lookups.mu.Unlock()
+	if ok {
+		return description
+	}
+
+	description = readServiceDescription(serviceName)
+
+	lookups.mu.Lock()
+	if lookups.descriptions == nil {
+		lookups.descriptions = make(map[string]string)
+	}
+	lookups.descriptions[serviceName] = description
+	lookups.mu.Unlock()
+
+	return description
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"systemctl-alpine/pkg/backend"
+	"systemctl-alpine/pkg/config"
+	"systemctl-alpine/pkg/paths"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// compatRoot builds a root directory with a unit that is converted and
+// enabled, a static unit, an OpenRC-only script and a disabled unit
+func compatRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	files := map[string]string{
+		"lib/systemd/system/webapp.service": `[Unit]
+Description=Example web application
+After=network.target
+
+[Service]
+ExecStart=/usr/bin/webapp --port 8080
+ExecReload=/bin/kill -HUP $MAINPID
+
+[Install]
+WantedBy=multi-user.target
+`,
+		"lib/systemd/system/batch.service": `[Unit]
+Description=Batch job
+
+[Service]
+Type=oneshot
+ExecStart=/usr/bin/batch
+
+[Install]
+WantedBy=multi-user.target
+`,
+		"lib/systemd/system/worker.service": `[Unit]
+Description=Worker started by webapp
+
+[Service]
+ExecStart=/usr/bin/worker
+`,
+		"etc/init.d/legacy": `#!/sbin/openrc-run
+description="Legacy OpenRC service"
+command="/usr/sbin/legacy"
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+// resetFlags sets every flag of a command and its subcommands back to its
+// default, since cobra keeps the values of earlier runs
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// runSystemctl runs a command line against the root directory and returns
+// what it printed on stdout and its exit status
+func runSystemctl(t *testing.T, root string, args ...string) (string, int) {
+	t.Helper()
+
+	resetFlags(rootCmd)
+	resetLookups()
+	defer paths.SetRoot("/")
+
+	// Commands print with fmt, so the output is captured through files
+	capture := func(name string) *os.File {
+		f, err := os.Create(filepath.Join(t.TempDir(), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	outFile, errFile := capture("stdout"), capture("stderr")
+	defer outFile.Close()
+	defer errFile.Close()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outFile, errFile
+	rootCmd.SetErr(errFile)
+
+	rootCmd.SetArgs(append([]string{"--root", root}, args...))
+	err := Execute()
+
+	os.Stdout, os.Stderr = stdout, stderr
+	rootCmd.SetErr(nil)
+
+	out, _ := os.ReadFile(outFile.Name())
+	if errOut, _ := os.ReadFile(errFile.Name()); len(errOut) > 0 {
+		t.Logf("stderr of systemctl %s:\n%s", strings.Join(args, " "), errOut)
+	}
+	return string(out), ExitCode(err)
+}
+
+// TestConfigurationManagementCommands runs the command lines that the
+// service modules of Ansible, Salt and Puppet issue and compares their
+// output and exit status with testdata/<name>.golden. Run the tests with
+// -update to rewrite the files.
+func TestConfigurationManagementCommands(t *testing.T) {
+	// Use the configuration of the root, which has none, rather than the
+	// one the environment names
+	t.Setenv(config.EnvPath, "")
+	os.Unsetenv(config.EnvPath)
+
+	fake := backend.NewFake()
+	fake.AddService("webapp", "stopped")
+	fake.AddService("batch", "stopped")
+	fake.AddService("worker", "stopped")
+	fake.AddService("legacy", "crashed")
+	previous := manager
+	SetServiceManager(fake)
+	t.Cleanup(func() { SetServiceManager(previous) })
+
+	root := compatRoot(t)
+
+	// Converting and enabling is what a play does before querying
+	if out, code := runSystemctl(t, root, "enable", "--now", "--", "webapp"); code != ExitSuccess {
+		t.Fatalf("enable --now webapp exited with %d:\n%s", code, out)
+	}
+	if calls := fake.Calls(); !slices.Contains(calls, "enable webapp default") || !slices.Contains(calls, "start webapp") {
+		t.Fatalf("enable --now webapp made the calls %v", calls)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		// Ansible systemd and service modules
+		{"ansible-show", []string{"show", "webapp"}},
+		{"ansible-show-not-found", []string{"show", "missing"}},
+		{"ansible-list-unit-files", []string{"list-unit-files", "webapp"}},
+		{"ansible-list-unit-files-no-match", []string{"list-unit-files", "missing"}},
+		{"ansible-is-enabled", []string{"is-enabled", "webapp"}},
+		{"ansible-is-active", []string{"is-active", "--", "webapp"}},
+		{"ansible-service-facts-units", []string{"list-units", "--no-pager", "--type", "service", "--all", "--plain"}},
+		{"ansible-service-facts-unit-files", []string{"list-unit-files", "--no-pager", "--type", "service", "--all"}},
+
+		// Puppet systemd provider
+		{"puppet-need-daemon-reload", []string{"show", "--property=NeedDaemonReload", "--", "webapp"}},
+		{"puppet-is-enabled-static", []string{"is-enabled", "--full", "--", "worker"}},
+		{"puppet-is-enabled-openrc", []string{"is-enabled", "--full", "--", "legacy"}},
+		{"puppet-is-enabled-disabled", []string{"is-enabled", "--full", "--", "batch"}},
+		{"puppet-is-active-failed", []string{"is-active", "--", "legacy"}},
+		{"puppet-list-unit-files", []string{"list-unit-files", "--no-pager", "--type=service"}},
+
+		// Salt systemd module
+		{"salt-version", []string{"--version"}},
+		{"salt-show-properties", []string{"show", "--property=ActiveState,SubState,UnitFileState,LoadState", "webapp"}},
+		{"salt-is-enabled", []string{"is-enabled", "webapp", "legacy"}},
+		{"salt-list-units", []string{"list-units", "--all", "--full", "--no-legend", "--no-pager"}},
+		{"salt-list-unit-files", []string{"list-unit-files", "--full", "--no-legend", "--no-pager"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, code := runSystemctl(t, root, tt.args...)
+			got := fmt.Sprintf("$ systemctl %s\n%s[exit status %d]\n", strings.Join(tt.args, " "), out, code)
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run the tests with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("output of systemctl %s differs from %s:\ngot:\n%s\nwant:\n%s", strings.Join(tt.args, " "), golden, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"systemctl-alpine/pkg/converter"
+	"systemctl-alpine/pkg/manifest"
+	"systemctl-alpine/pkg/merge"
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
+)
+
+// modifiedMarker is added to scripts changed with `systemctl edit`; such
+// scripts are not replaced by conversions unless --force is given
+const modifiedMarker = "# Modified by systemctl edit"
+
+// isModifiedScript reports whether a service's OpenRC script carries the
+// modification marker
+func isModifiedScript(serviceName string) bool {
+	content, err := os.ReadFile(paths.InitScript(serviceName))
+	return err == nil && strings.Contains(string(content), modifiedMarker)
+}
+
+// errMergeConflict is returned when merging a regenerated script with the
+// administrator's edits left conflict markers in the script
+var errMergeConflict = errors.New("merge conflicts")
+
+// convertService converts a unit file into an OpenRC script and
+// configuration file, writes them and records the conversion in the
+// manifest. A configuration file that was not generated, or that was
+// changed since it was generated, is kept unless --force is given.
+func convertService(openrcName, unitFile, instanceName string) error {
+	return writeConversion(openrcName, unitFile, instanceName, false)
+}
+
+// mergeService converts a service whose OpenRC script has been edited. The
+// changes between the previously and the newly generated script are merged
+// into the edited script (a three-way merge with the pristine copy kept by
+// every conversion as the base). Returns fs.ErrNotExist if there is no
+// pristine copy and errMergeConflict if the merge left conflict markers.
+func mergeService(openrcName, unitFile, instanceName string) error {
+	return writeConversion(openrcName, unitFile, instanceName, true)
+}
+
+// writeConversion implements convertService and mergeService
+func writeConversion(openrcName, unitFile, instanceName string, mergeEdits bool) error {
+	unit, err := parser.ParseServiceFile(unitFile, instanceName)
+	if err != nil {
+		return fmt.Errorf("failed to parse service file: %w", err)
+	}
+
+	conversion, err := converter.ConvertToOpenRC(unit, openrcName, instanceName)
+	if err != nil {
+		return fmt.Errorf("failed to convert to OpenRC: %w", err)
+	}
+
+	sources, err := manifest.HashFiles(conversionSources(openrcName, unit))
+	if err != nil {
+		return fmt.Errorf("failed to read sources: %w", err)
+	}
+
+	m, err := manifest.Load()
+	if err != nil {
+		return err
+	}
+	previous := m.Units[openrcName]
+
+	script := conversion.Script
+	conflict := false
+	if mergeEdits {
+		if script, conflict, err = mergeScript(openrcName, unitFile, conversion.Script); err != nil {
+			return err
+		}
+	}
+
+	if err := converter.WriteOpenRCScript(script, openrcName); err != nil {
+		return fmt.Errorf("failed to write OpenRC script: %w", err)
+	}
+	if err := manifest.WritePristine(openrcName, conversion.Script); err != nil {
+		return err
+	}
+
+	confPath := paths.ConfFile(openrcName)
+	confSum := manifest.Checksum([]byte(conversion.ConfFile))
+	generated := converter.IsGeneratedConfFile(openrcName)
+	if forceFlag || generated && !isConfFileModified(openrcName, previous) {
+		if err := converter.WriteConfFile(conversion.ConfFile, openrcName, conversion.ConfMode); err != nil {
+			return fmt.Errorf("failed to write OpenRC configuration: %w", err)
+		}
+	} else {
+		state := "modified"
+		if !generated {
+			state = "existing"
+		}
+		fmt.Printf("Keeping %s %s; use --force to replace it with the converted settings.\n", state, paths.Unresolve(confPath))
+		confSum = ""
+		if previous != nil {
+			confSum = previous.ConfSHA256
+		}
+	}
+
+	m.Units[openrcName] = &manifest.Entry{
+		Service:      openrcName,
+		Unit:         paths.Unresolve(unitFile),
+		Instance:     instanceName,
+		Sources:      sources,
+		ToolVersion:  Version,
+		ScriptSHA256: manifest.Checksum([]byte(conversion.Script)),
+		ConfSHA256:   confSum,
+		ConvertedAt:  time.Now().UTC(),
+	}
+
+	if err := m.Save(); err != nil {
+		return err
+	}
+	resetLookups()
+	if conflict {
+		return errMergeConflict
+	}
+	return nil
+}
+
+// mergeScript merges a newly generated script into the edited script of a
+// service and returns the result and whether it has conflict markers
+func mergeScript(openrcName, unitFile, generated string) (string, bool, error) {
+	pristine, err := manifest.ReadPristine(openrcName)
+	if err != nil {
+		return "", false, err
+	}
+
+	scriptPath := paths.InitScript(openrcName)
+	edited, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read OpenRC script: %w", err)
+	}
+
+	merged, conflict := merge.ThreeWay(pristine, string(edited), generated, merge.Labels{
+		Base:   "previously generated",
+		Ours:   paths.Unresolve(scriptPath) + " (edited)",
+		Theirs: "generated from " + paths.Unresolve(unitFile),
+	})
+
+	return merged, conflict, nil
+}
+
+// isConfFileModified reports whether the configuration file of a service
+// differs from the one written by its last conversion
+func isConfFileModified(serviceName string, entry *manifest.Entry) bool {
+	if entry == nil || entry.ConfSHA256 == "" {
+		return false
+	}
+	content, err := os.ReadFile(paths.ConfFile(serviceName))
+	if err != nil {
+		return false
+	}
+	return manifest.Checksum(content) != entry.ConfSHA256
+}
+
+// conversionSources returns the files a conversion depends on, as seen from
+// inside the root: the unit file, its drop-ins, its environment files and
+// the override template, if any
+func conversionSources(openrcName string, unit *parser.ServiceConfig) []string {
+	sources := []string{paths.Unresolve(unit.SourcePath)}
+	for _, dropIn := range unit.DropInPaths {
+		sources = append(sources, paths.Unresolve(dropIn))
+	}
+	for _, file := range unit.EnvironmentFiles {
+		sources = append(sources, file.Path)
+	}
+	if template := converter.TemplatePath(openrcName); template != "" {
+		sources = append(sources, paths.Unresolve(template))
+	}
+	return sources
+}
@@ -1,26 +1,138 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"systemctl-alpine/pkg/manifest"
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
 
 	"github.com/spf13/cobra"
 )
 
 var daemonReloadCmd = &cobra.Command{
 	Use:   "daemon-reload",
-	Short: "Reload systemd manager configuration (not needed in OpenRC)",
-	Long: `In systemd, daemon-reload is used to reload unit files and restart systemd services.
-This command is not needed in OpenRC as service scripts are read directly each time.
-
-This command is provided for compatibility with systemd workflows.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("The 'daemon-reload' command is not needed in OpenRC.")
-		fmt.Println("OpenRC reads service scripts directly each time they are used.")
-		fmt.Println("No action was performed.")
+	Short: "Convert changed unit files to OpenRC again",
+	Long: `Convert services again whose unit files have changed since they were converted.
+
+Every conversion is recorded in /var/lib/systemctl-alpine/conversions.json with
+the checksums of its sources: the unit file, its drop-ins, its environment
+files and the override template. daemon-reload converts every service whose
+sources changed, for example after a package upgrade, or whose unit file is now
+found in a location of higher precedence.
+
+Scripts that were edited, with 'systemctl edit --openrc' or otherwise, are not
+overwritten: the changes between the previously and the newly generated script
+are merged into them. If the edits conflict with the changes, conflict markers
+are left in the script and daemon-reload fails.
+
+Example:
+  ` + cliName + ` daemon-reload`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manifest.Load()
+		if err != nil {
+			return exitWith(ExitFailure, err)
+		}
+
+		failed := 0
+		for _, name := range m.Services() {
+			if err := reloadService(m.Units[name]); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to convert %s: %v\n", name, err)
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return exitWith(ExitFailure, fmt.Errorf("%d of %d services could not be converted", failed, len(m.Units)))
+		}
+		return nil
 	},
 	SilenceUsage: true,
 }
 
+// reloadService converts a recorded service again if its sources changed
+func reloadService(entry *manifest.Entry) error {
+	name := entry.Service
+
+	unitFile, changed, err := changedSources(entry)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		fmt.Printf("Skipping %s: unit file %s no longer exists\n", name, entry.Unit)
+		return nil
+	case err != nil:
+		return err
+	case len(changed) == 0:
+		return nil
+	}
+
+	scriptPath := paths.InitScript(name)
+	edited := isModifiedScript(name)
+	if content, err := os.ReadFile(scriptPath); err == nil && manifest.Checksum(content) != entry.ScriptSHA256 {
+		edited = true
+	}
+
+	if !edited {
+		if err := convertService(name, unitFile, entry.Instance); err != nil {
+			return err
+		}
+		fmt.Printf("Converted %s again: %s changed\n", name, strings.Join(changed, ", "))
+		return nil
+	}
+
+	// Merge the changes into the edited script
+	err = mergeService(name, unitFile, entry.Instance)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		fmt.Printf("Skipping %s: %s has been edited and there is no pristine copy to merge with\n", name, paths.Unresolve(scriptPath))
+		return nil
+	case errors.Is(err, errMergeConflict):
+		return fmt.Errorf("%s changed and the merge into the edited %s has conflicts; resolve the conflict markers", strings.Join(changed, ", "), paths.Unresolve(scriptPath))
+	case err != nil:
+		return err
+	}
+
+	fmt.Printf("Merged changes to %s into edited %s: %s changed\n", name, paths.Unresolve(scriptPath), strings.Join(changed, ", "))
+	return nil
+}
+
+// changedSources returns the unit file of a recorded service and the
+// sources that changed since the service was converted. Returns
+// fs.ErrNotExist if the unit file no longer exists.
+func changedSources(entry *manifest.Entry) (string, []string, error) {
+	unitFile, found := findUnitFile(entry.Service)
+	if !found {
+		return "", nil, fs.ErrNotExist
+	}
+
+	unit, err := parser.ParseServiceFile(unitFile, entry.Instance)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sources, err := manifest.HashFiles(conversionSources(entry.Service, unit))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return unitFile, manifest.ChangedSources(entry.Sources, sources), nil
+}
+
+// needsDaemonReload reports whether daemon-reload would convert a service
+// again, for the NeedDaemonReload property
+func needsDaemonReload(serviceName string) bool {
+	m, err := manifest.Load()
+	if err != nil || m.Units[serviceName] == nil {
+		return false
+	}
+	_, changed, err := changedSources(m.Units[serviceName])
+	return err == nil && len(changed) > 0
+}
+
 func init() {
 	rootCmd.AddCommand(daemonReloadCmd)
 }
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/util"
+)
+
+// serviceDeps is what a service declares about its ordering: the services
+// it comes after, those it comes before, and the virtual names it provides
+type serviceDeps struct {
+	after   []string
+	before  []string
+	provide []string
+}
+
+// getServiceDeps reads the ordering of a service from the depend() function
+// of its OpenRC script and from After=, Before=, Requires=, Wants= and
+// BindsTo= of its unit file
+func getServiceDeps(serviceName string) serviceDeps {
+	var deps serviceDeps
+
+	depends := scriptDependencies(serviceName)
+	for _, keyword := range []string{"need", "use", "want", "after"} {
+		deps.after = append(deps.after, depends[keyword]...)
+	}
+	deps.before = depends["before"]
+	deps.provide = depends["provide"]
+
+	if unitFile, found := findUnitFile(serviceName); found {
+		_, instanceName := splitUnitName(serviceName)
+		if unit, err := parser.ParseServiceFile(unitFile, instanceName); err == nil {
+			for _, key := range []string{"After", "Requires", "Wants", "BindsTo"} {
+				deps.after = append(deps.after, unitNames(unit.Unit.All("Unit", key))...)
+			}
+			deps.before = append(deps.before, unitNames(unit.Unit.All("Unit", "Before"))...)
+		}
+	}
+
+	return deps
+}
+
+// scriptDependencies returns the entries of the depend() function of a
+// service's OpenRC script by keyword (need, use, want, after, before,
+// provide)
+func scriptDependencies(serviceName string) map[string][]string {
+	depends := make(map[string][]string)
+
+	content, err := os.ReadFile(paths.InitScript(serviceName))
+	if err != nil {
+		return depends
+	}
+	for _, line := range dependLines(string(content)) {
+		if fields := strings.Fields(line); len(fields) > 1 {
+			depends[fields[0]] = append(depends[fields[0]], fields[1:]...)
+		}
+	}
+
+	return depends
+}
+
+// dependLines returns the lines of the depend() function of an OpenRC script
+func dependLines(script string) []string {
+	var lines []string
+	inDepend := false
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case !inDepend:
+			inDepend = strings.HasPrefix(line, "depend()") || strings.HasPrefix(line, "depend ()")
+		case line == "}":
+			return lines
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// unitNames turns space-separated unit lists into service names, dropping
+// units of other types such as targets
+func unitNames(values []string) []string {
+	var names []string
+	for _, value := range values {
+		for _, unit := range strings.Fields(value) {
+			if strings.Contains(unit, ".") && !strings.HasSuffix(unit, ".service") {
+				continue
+			}
+			names = append(names, util.NormalizeServiceName(unit))
+		}
+	}
+	return names
+}
+
+// orderServices sorts services so that every service comes after the
+// services it depends on, as far as they are among the given ones. Services
+// without an order between them, and services in a dependency cycle, keep
+// their order.
+func orderServices(services []string) []string {
+	if len(services) < 2 {
+		return services
+	}
+
+	// Names, including provided virtual names, of the given services
+	index := make(map[string][]int)
+	deps := make([]serviceDeps, len(services))
+	for i, service := range services {
+		deps[i] = getServiceDeps(service)
+		index[service] = append(index[service], i)
+		for _, name := range deps[i].provide {
+			index[name] = append(index[name], i)
+		}
+	}
+
+	// edges[i] lists the services that must come after service i
+	edges := make([][]int, len(services))
+	incoming := make([]int, len(services))
+	addEdge := func(from, to int) {
+		if from != to && !slices.Contains(edges[from], to) {
+			edges[from] = append(edges[from], to)
+			incoming[to]++
+		}
+	}
+	for i := range services {
+		for _, name := range deps[i].after {
+			for _, j := range index[name] {
+				addEdge(j, i)
+			}
+		}
+		for _, name := range deps[i].before {
+			for _, j := range index[name] {
+				addEdge(i, j)
+			}
+		}
+	}
+
+	// Repeatedly take the first service in the given order whose
+	// dependencies have all been taken
+	ordered := make([]string, 0, len(services))
+	done := make([]bool, len(services))
+	for len(ordered) < len(services) {
+		next := -1
+		for i := range services {
+			if !done[i] && incoming[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// A cycle: take the first remaining service
+			for i := range services {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+
+		done[next] = true
+		ordered = append(ordered, services[next])
+		for _, j := range edges[next] {
+			incoming[j]--
+		}
+	}
+
+	return ordered
+}
@@ -2,7 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
+	"slices"
 
 	"systemctl-alpine/pkg/util"
 
@@ -12,44 +13,72 @@ import (
 var disableCmd = &cobra.Command{
 	Use:   "disable [service...]",
 	Short: "Disable one or more services from starting at boot",
-	Long: `Disable one or more services from starting at boot by removing them from the default runlevel.
+	Long: `Disable one or more services from starting at boot by removing them from every runlevel.
 
 Example:
   ` + cliName + ` disable nginx
   ` + cliName + ` disable --now nginx mysql redis  # Stop and disable multiple services`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// As with enable, a service that cannot be stopped or disabled is
+		// reported and the others are still processed; the exit status is
+		// 1 if any of them failed
+		exitCode := ExitSuccess
+		fail := func(action, serviceName string, err error) {
+			fmt.Fprintf(os.Stderr, "Failed to %s %s: %v\n", action, serviceName, err)
+			exitCode = ExitFailure
+		}
+
+		var services []string
 		for _, arg := range args {
-			if err := disableService(arg); err != nil {
-				return fmt.Errorf("failed to disable %s: %w", arg, err)
+			serviceName := util.NormalizeServiceName(arg)
+			if err := checkServiceExists(serviceName); err != nil {
+				fail("disable", arg, err)
+				continue
 			}
+			services = append(services, serviceName)
 		}
-		return nil
+
+		// Stop the services if --now flag is provided, before the services
+		// they depend on
+		if nowFlag && !isOffline() {
+			stopOrder := orderServices(services)
+			slices.Reverse(stopOrder)
+			for _, serviceName := range stopOrder {
+				if err := executeServiceCommand(serviceName, "stop"); err != nil {
+					fail("stop", serviceName, err)
+				}
+			}
+		}
+
+		// The services are removed from their runlevels in parallel
+		errs := parallelMap(services, disableService)
+		resetLookups()
+
+		for i, serviceName := range services {
+			if errs[i] != nil {
+				fail("disable", serviceName, errs[i])
+				continue
+			}
+			fmt.Printf("Service %s has been disabled\n", serviceName)
+		}
+		return exitSilently(exitCode)
 	},
 	SilenceUsage: true,
 }
 
+// disableService removes a service from every runlevel it was added to
 func disableService(serviceName string) error {
-	serviceName = util.NormalizeServiceName(serviceName)
-
-	if err := checkServiceExists(serviceName); err != nil {
-		return err
+	runlevels := getServiceRunlevels(serviceName)
+	if len(runlevels) == 0 {
+		runlevels = []string{cfg.DefaultRunlevel}
 	}
-
-	// Stop the service if --now flag is provided
-	if nowFlag {
-		if err := executeServiceCommand(serviceName, "stop"); err != nil {
-			return err
+	for _, runlevel := range runlevels {
+		if err := manager.Disable(serviceName, runlevel); err != nil {
+			return fmt.Errorf("failed to disable service: %w", err)
 		}
 	}
 
-	// Disable the service
-	disableCmd := exec.Command("rc-update", "del", serviceName, "default")
-	if err := disableCmd.Run(); err != nil {
-		return fmt.Errorf("failed to disable service: %w", err)
-	}
-
-	fmt.Printf("Service %s has been disabled\n", serviceName)
 	return nil
 }
 
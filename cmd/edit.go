@@ -8,40 +8,86 @@ import (
 	"strings"
 	"time"
 
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
 	"systemctl-alpine/pkg/util"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	editDropInFlag string
+	editOpenRCFlag bool
+)
+
+// Markers delimiting the part of a drop-in edit that is kept, as in systemd
+const (
+	editHeaderLine  = "### Anything between here and the comment below will become the contents of the drop-in file"
+	editDiscardLine = "### Edits below this comment will be discarded"
+)
+
 var editCmd = &cobra.Command{
-	Use:   "edit [service]",
-	Short: "Edit an OpenRC service script",
-	Long: `Edit an OpenRC service script using the system's available editor.
+	Use:   "edit [service...]",
+	Short: "Edit a service with a drop-in, its full unit file or its OpenRC script",
+	Long: `Edit a service the way systemd does.
+
+By default a drop-in, /etc/systemd/system/<service>.service.d/override.conf, is
+opened in an editor with the current unit file shown below it for reference.
+--drop-in=NAME edits NAME.conf instead. With --full the whole unit file is
+edited; a vendor unit file is first copied to /etc/systemd/system.
+
+The edit is made in a temporary file, checked when the editor exits and then
+moved into place. A service that has been converted before is converted again.
+An empty drop-in is removed.
+
+With --openrc the generated OpenRC script is edited directly instead. It is
+then marked as modified so that later conversions do not overwrite it.
+
+The editor is taken from $SYSTEMD_EDITOR, $EDITOR or $VISUAL, falling back to
+vi, nano or ed.
 
 Example:
-  ` + cliName + ` edit nginx`,
-	Args: cobra.ExactArgs(1),
+  ` + cliName + ` edit nginx
+  ` + cliName + ` edit --drop-in=limits nginx
+  ` + cliName + ` edit --full nginx
+  ` + cliName + ` edit --openrc nginx`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		serviceName := util.NormalizeServiceName(args[0])
+		if editOpenRCFlag && (fullFlag || editDropInFlag != "") {
+			return exitWith(ExitInvalidArgument, fmt.Errorf("--openrc cannot be combined with --full or --drop-in"))
+		}
+		if fullFlag && editDropInFlag != "" {
+			return exitWith(ExitInvalidArgument, fmt.Errorf("--full cannot be combined with --drop-in"))
+		}
 
-		if err := checkServiceExists(serviceName); err != nil {
-			return err
+		for _, arg := range args {
+			serviceName := util.NormalizeServiceName(arg)
+
+			var err error
+			switch {
+			case editOpenRCFlag:
+				if err = checkServiceExists(serviceName); err == nil {
+					err = editService(serviceName)
+				}
+			case fullFlag:
+				err = editUnitFile(serviceName)
+			default:
+				err = editDropIn(serviceName, editDropInFlag)
+			}
+			if err != nil {
+				return exitWith(ExitFailure, err)
+			}
 		}
 
-		return editService(serviceName)
+		return nil
 	},
 	SilenceUsage: true,
 }
 
+// editService edits the OpenRC script of a service and marks it as modified
 func editService(serviceName string) error {
 	// Path to the OpenRC service script
-	scriptPath := filepath.Join("/etc/init.d", serviceName)
-
-	// Find an available editor
-	editor := findEditor()
-	if editor == "" {
-		return fmt.Errorf("no editor found. Please install vi, nano, or ed")
-	}
+	scriptPath := paths.InitScript(serviceName)
 
 	// Read the current content of the file
 	content, err := os.ReadFile(scriptPath)
@@ -49,65 +95,261 @@ func editService(serviceName string) error {
 		return fmt.Errorf("failed to read service file: %w", err)
 	}
 
-	// Open the file in the editor
-	cmd := exec.Command(editor, scriptPath)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("editor exited with error: %w", err)
+	newContent, _, err := editInTempFile(string(content), serviceName)
+	if err != nil {
+		return err
 	}
 
-	// Read the file again to see if it was modified
-	newContent, err := os.ReadFile(scriptPath)
-	if err != nil {
-		return fmt.Errorf("failed to read service file after editing: %w", err)
+	if newContent == string(content) {
+		fmt.Printf("Service %s was not modified\n", serviceName)
+		return nil
 	}
 
-	// If the file was modified, add a modification comment
-	if string(content) != string(newContent) {
-		timestamp := time.Now().Format(time.RFC3339)
+	// The file was modified: add or update the modification comment
+	timestamp := time.Now().Format(time.RFC3339)
+	message := "Service %s has been modified and saved\n"
+	if strings.Contains(newContent, modifiedMarker) {
+		newContent = updateModificationTimestamp(newContent, timestamp)
+		message = "Service %s has been modified and saved (timestamp updated)\n"
+	} else {
+		newContent = strings.TrimRight(newContent, "\n") + fmt.Sprintf("\n\n%s on %s\n", modifiedMarker, timestamp)
+	}
 
-		// Check if the file already has a modification comment
-		if strings.Contains(string(newContent), "# Modified by systemctl edit") {
-			// Update the existing modification comment with a new timestamp
-			updatedContent := updateModificationTimestamp(string(newContent), timestamp)
+	if err := util.WriteFileAtomic(scriptPath, []byte(newContent), 0755); err != nil {
+		return fmt.Errorf("failed to write service file: %w", err)
+	}
 
-			// Write the updated content back to the file
-			if err := os.WriteFile(scriptPath, []byte(updatedContent), 0755); err != nil {
-				return fmt.Errorf("failed to update modification timestamp: %w", err)
-			}
+	fmt.Printf(message, serviceName)
+	return nil
+}
 
-			fmt.Printf("Service %s has been modified and saved (timestamp updated)\n", serviceName)
-		} else {
-			// Add new modification comment
-			modComment := fmt.Sprintf("\n# Modified by systemctl edit on %s\n", timestamp)
+// editDropIn edits a drop-in of a service in the systemd layout, with the
+// unit file shown as a comment below the part that is kept
+func editDropIn(serviceName, dropInName string) error {
+	unitFile, found := findUnitFile(serviceName)
+	if !found {
+		return fmt.Errorf("no unit file found for %s; use --openrc to edit its OpenRC script", serviceName)
+	}
 
-			// Append the comment to the file
-			f, err := os.OpenFile(scriptPath, os.O_APPEND|os.O_WRONLY, 0644)
-			if err != nil {
-				return fmt.Errorf("failed to open file for appending: %w", err)
-			}
-			defer f.Close()
+	if dropInName == "" {
+		dropInName = "override"
+	}
+	if !strings.HasSuffix(dropInName, ".conf") {
+		dropInName += ".conf"
+	}
+	if strings.ContainsRune(dropInName, '/') {
+		return exitWith(ExitInvalidArgument, fmt.Errorf("invalid drop-in name %q", dropInName))
+	}
 
-			if _, err := f.WriteString(modComment); err != nil {
-				return fmt.Errorf("failed to append modification comment: %w", err)
-			}
+	unitName := util.NormalizeServiceName(serviceName) + ".service"
+	dropInPath := paths.Resolve(filepath.Join(paths.AdminUnitDir, unitName+".d", dropInName))
+
+	current, err := os.ReadFile(dropInPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read drop-in: %w", err)
+	}
+	existed := err == nil
+
+	unitContent, err := os.ReadFile(unitFile)
+	if err != nil {
+		return fmt.Errorf("failed to read unit file: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Editing %s\n%s\n\n", paths.Unresolve(dropInPath), editHeaderLine)
+	if existed {
+		b.WriteString(strings.TrimSpace(string(current)) + "\n")
+	}
+	fmt.Fprintf(&b, "\n%s\n\n### %s\n", editDiscardLine, paths.Unresolve(unitFile))
+	for _, line := range strings.Split(strings.TrimRight(string(unitContent), "\n"), "\n") {
+		b.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+	}
+
+	edited, tmpPath, err := editInTempFile(b.String(), unitName)
+	if err != nil {
+		return err
+	}
+	dropIn := extractDropIn(edited)
 
-			fmt.Printf("Service %s has been modified and saved\n", serviceName)
+	if dropIn == "" {
+		if !existed {
+			fmt.Println("Editing canceled: drop-in is empty.")
+			return nil
 		}
-	} else {
+		if err := os.Remove(dropInPath); err != nil {
+			return fmt.Errorf("failed to remove drop-in: %w", err)
+		}
+		// Remove the drop-in directory if this was its last file
+		os.Remove(filepath.Dir(dropInPath))
+		fmt.Printf("Removed %s\n", paths.Unresolve(dropInPath))
+		return reconvertEdited(serviceName)
+	}
+
+	if existed && dropIn == strings.TrimSpace(string(current))+"\n" {
 		fmt.Printf("Service %s was not modified\n", serviceName)
+		return nil
 	}
 
+	if err := saveEditedUnit(dropIn, dropInPath, tmpPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %s\n", paths.Unresolve(dropInPath))
+	return reconvertEdited(serviceName)
+}
+
+// editUnitFile edits the complete unit file of a service. Vendor unit files
+// are copied to /etc/systemd/system so that package upgrades do not
+// overwrite the edit.
+func editUnitFile(serviceName string) error {
+	unitFile, found := findUnitFile(serviceName)
+	if !found {
+		return fmt.Errorf("no unit file found for %s; use --openrc to edit its OpenRC script", serviceName)
+	}
+
+	content, err := os.ReadFile(unitFile)
+	if err != nil {
+		return fmt.Errorf("failed to read unit file: %w", err)
+	}
+
+	target := paths.Resolve(filepath.Join(paths.AdminUnitDir, filepath.Base(unitFile)))
+
+	edited, tmpPath, err := editInTempFile(string(content), filepath.Base(unitFile))
+	if err != nil {
+		return err
+	}
+
+	if edited == string(content) {
+		fmt.Printf("Service %s was not modified\n", serviceName)
+		return nil
+	}
+
+	if err := saveEditedUnit(edited, target, tmpPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %s\n", paths.Unresolve(target))
+	return reconvertEdited(serviceName)
+}
+
+// saveEditedUnit validates edited unit file content and moves it into
+// place. If the content is invalid it is written back to the temporary
+// file, so that the edit is not lost.
+func saveEditedUnit(content, target, tmpPath string) error {
+	if err := parser.Validate(content); err != nil {
+		if keepErr := os.WriteFile(tmpPath, []byte(content), 0644); keepErr == nil {
+			return fmt.Errorf("%s: %w (your changes were kept in %s)", paths.Unresolve(target), err, tmpPath)
+		}
+		return fmt.Errorf("%s: %w", paths.Unresolve(target), err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := util.WriteFileAtomic(target, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", paths.Unresolve(target), err)
+	}
+	resetLookups()
+
+	return nil
+}
+
+// reconvertEdited converts a service again after its unit file or drop-ins
+// were edited. Services that were never converted are left alone.
+func reconvertEdited(serviceName string) error {
+	if _, err := os.Stat(paths.InitScript(serviceName)); err != nil {
+		return nil
+	}
+
+	if isModifiedScript(serviceName) && !forceFlag {
+		fmt.Printf("Not converting %s again: its OpenRC script has been modified by systemctl edit. Use --force to overwrite.\n", serviceName)
+		return nil
+	}
+
+	unitFile, found := findUnitFile(serviceName)
+	if !found {
+		return nil
+	}
+	_, instanceName := splitUnitName(serviceName)
+
+	if err := convertService(serviceName, unitFile, instanceName); err != nil {
+		return err
+	}
+
+	fmt.Printf("Service %s has been converted to OpenRC\n", serviceName)
 	return nil
 }
 
+// extractDropIn returns the part of an edited drop-in between the header
+// and the discard marker
+func extractDropIn(edited string) string {
+	if _, rest, ok := strings.Cut(edited, editHeaderLine); ok {
+		edited = rest
+	}
+	if kept, _, ok := strings.Cut(edited, editDiscardLine); ok {
+		edited = kept
+	}
+
+	edited = strings.TrimSpace(edited)
+	if edited == "" {
+		return ""
+	}
+	return edited + "\n"
+}
+
+// editInTempFile writes content to a temporary file, opens it in the editor
+// and returns the edited content and the temporary file, which has been
+// removed unless the caller needs to keep it
+func editInTempFile(content, name string) (string, string, error) {
+	// Find an available editor
+	editor := findEditor()
+	if editor == "" {
+		return "", "", fmt.Errorf("no editor found. Please install vi, nano, or ed")
+	}
+
+	tmp, err := os.CreateTemp("", "systemctl-edit-*-"+name)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmp.WriteString(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// Open the file in the editor; the editor may be a command with arguments
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], tmpPath)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("editor exited with error: %w", err)
+	}
+
+	edited, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read temporary file after editing: %w", err)
+	}
+
+	return string(edited), tmpPath, nil
+}
+
 // findEditor looks for available editors in the system
 func findEditor() string {
-	// Check for environment variable
-	if editor := os.Getenv("EDITOR"); editor != "" {
-		if _, err := exec.LookPath(editor); err == nil {
+	// Check the environment variables systemd uses
+	for _, variable := range []string{"SYSTEMD_EDITOR", "EDITOR", "VISUAL"} {
+		editor := os.Getenv(variable)
+		fields := strings.Fields(editor)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(fields[0]); err == nil {
 			return editor
 		}
 	}
@@ -127,8 +369,8 @@ func findEditor() string {
 func updateModificationTimestamp(content, timestamp string) string {
 	lines := strings.Split(content, "\n")
 	for i, line := range lines {
-		if strings.Contains(line, "# Modified by systemctl edit on ") {
-			lines[i] = "# Modified by systemctl edit on " + timestamp
+		if strings.Contains(line, modifiedMarker+" on ") {
+			lines[i] = modifiedMarker + " on " + timestamp
 		}
 	}
 	return strings.Join(lines, "\n")
@@ -136,4 +378,7 @@ func updateModificationTimestamp(content, timestamp string) string {
 
 func init() {
 	rootCmd.AddCommand(editCmd)
+	editCmd.Flags().StringVar(&editDropInFlag, "drop-in", "", "Edit the named drop-in instead of override.conf")
+	editCmd.Flags().BoolVar(&editOpenRCFlag, "openrc", false, "Edit the generated OpenRC script instead of the unit file")
+	editCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Convert again even if the OpenRC script was modified")
 }
@@ -1,13 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"strings"
 
-	"systemctl-alpine/pkg/converter"
+	"systemctl-alpine/pkg/backend"
 	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
 	"systemctl-alpine/pkg/util"
 
 	"github.com/spf13/cobra"
@@ -24,58 +26,81 @@ Example:
   ` + cliName + ` enable --now nginx mysql redis  # Enable and start multiple services`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// Like systemd, a service that cannot be enabled or started is
+		// reported and the others are still enabled, and started with
+		// --now; the exit status is 1 if any of them failed
+		exitCode := ExitSuccess
+		fail := func(action, serviceName string, err error) {
+			fmt.Fprintf(os.Stderr, "Failed to %s %s: %v\n", action, serviceName, err)
+			exitCode = ExitFailure
+		}
+
+		// Services are converted one at a time, and then added to their
+		// runlevels in parallel
+		var jobs []enableJob
 		for _, arg := range args {
-			if err := enableService(arg); err != nil {
-				return fmt.Errorf("failed to enable %s: %w", arg, err)
+			job, err := prepareEnable(arg)
+			if err != nil {
+				fail("enable", arg, err)
+				continue
 			}
+			jobs = append(jobs, job)
 		}
-		return nil
-	},
-	SilenceUsage: true,
-}
 
-func enableService(serviceName string) error {
-	// Check if this is a template service (contains @)
-	var templateName string
-	var instanceName string
+		errs := parallelMap(jobs, func(job enableJob) error {
+			return manager.Enable(job.name, job.runlevel)
+		})
+		resetLookups()
 
-	if strings.Contains(serviceName, "@") {
-		parts := strings.SplitN(serviceName, "@", 2)
-		templateName = parts[0] + "@"
-		instanceName = parts[1]
-
-		// If the service name doesn't end with .service, add it for the template
-		if !strings.HasSuffix(templateName, ".service") {
-			templateName = templateName + ".service"
+		var enabled []string
+		for i, job := range jobs {
+			if errs[i] != nil {
+				fail("enable", job.name, errs[i])
+				continue
+			}
+			fmt.Printf("Service %s has been enabled\n", job.name)
+			enabled = append(enabled, job.name)
 		}
-	} else {
-		// If the service name doesn't end with .service, add it
-		templateName = util.NormalizeServiceName(serviceName)
-		if !strings.HasSuffix(templateName, ".service") {
-			templateName = templateName + ".service"
+
+		// Start the services if --now flag is provided
+		for _, serviceName := range orderServices(enabled) {
+			if err := startIfRequested(serviceName); err != nil {
+				fail("start", serviceName, err)
+			}
 		}
-	}
+		return exitSilently(exitCode)
+	},
+	SilenceUsage: true,
+}
 
+// enableJob is a service to add to a runlevel
+type enableJob struct {
+	name     string
+	runlevel string
+}
+
+// prepareEnable converts a service, unless its script was modified, and
+// returns the runlevel to enable it in
+func prepareEnable(serviceName string) (enableJob, error) {
 	// The OpenRC service name will include the instance name if provided
 	openrcName := util.NormalizeServiceName(serviceName)
+	templateName, instanceName := splitUnitName(serviceName)
 
 	// Check if the OpenRC service already exists
-	openrcPath := filepath.Join("/etc/init.d", openrcName)
+	openrcPath := paths.InitScript(openrcName)
 	openrcExists := false
 	if _, err := os.Stat(openrcPath); err == nil {
 		openrcExists = true
 	}
 
 	// Look for the systemd service file (using the template name)
-	var serviceFile string
-	var found bool
-
-	for _, location := range serviceLocations {
-		path := filepath.Join(location, templateName)
-		if _, err := os.Stat(path); err == nil {
-			serviceFile = path
-			found = true
-			break
+	serviceFile, found := findUnitFile(serviceName)
+
+	// The runlevel follows the unit's WantedBy= and RequiredBy= targets
+	runlevel := cfg.DefaultRunlevel
+	if found {
+		if unit, err := parser.ParseServiceFile(serviceFile, instanceName); err == nil {
+			runlevel = cfg.Runlevel(unit.WantedBy + " " + unit.RequiredBy)
 		}
 	}
 
@@ -83,111 +108,68 @@ func enableService(serviceName string) error {
 	if openrcExists {
 		content, err := os.ReadFile(openrcPath)
 		if err != nil {
-			return fmt.Errorf("failed to read service file: %w", err)
+			return enableJob{}, fmt.Errorf("failed to read service file: %w", err)
 		}
 
 		// Check for modification comment
-		if strings.Contains(string(content), "# Modified by systemctl edit") && !forceFlag {
+		if strings.Contains(string(content), modifiedMarker) && !forceFlag {
 			fmt.Printf("Service %s has been manually modified. Use --force to overwrite.\n", openrcName)
 
 			// If systemd service file not found, just enable the existing OpenRC service
 			if !found {
-				// Enable the service
-				if err := converter.EnableService(openrcName); err != nil {
-					return err
-				}
-
-				fmt.Printf("Service %s has been enabled\n", openrcName)
-
-				// Start the service if --now flag is provided
-				if nowFlag {
-					if err := executeServiceCommand(openrcName, "start"); err != nil {
-						return fmt.Errorf("failed to start service: %w", err)
-					}
-				}
-
-				return nil
+				return enableJob{openrcName, runlevel}, nil
 			}
 
-			// If systemd service file found but we're not forcing, just enable without converting
-			fmt.Printf("Skipping conversion due to manual modifications. Enabling existing service.\n")
-			if err := converter.EnableService(openrcName); err != nil {
-				return err
+			// If systemd service file found but we're not forcing, merge the
+			// converted unit into the modified script
+			err := mergeService(openrcName, serviceFile, instanceName)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				fmt.Printf("Skipping conversion due to manual modifications. Enabling existing service.\n")
+			case errors.Is(err, errMergeConflict):
+				return enableJob{}, fmt.Errorf("merging %s into %s left conflict markers; resolve them and enable the service again", paths.Unresolve(serviceFile), paths.Unresolve(openrcPath))
+			case err != nil:
+				return enableJob{}, err
+			default:
+				fmt.Printf("Merged changes from %s into modified %s\n", paths.Unresolve(serviceFile), paths.Unresolve(openrcPath))
 			}
-
-			fmt.Printf("Service %s has been enabled\n", openrcName)
-
-			// Start the service if --now flag is provided
-			if nowFlag {
-				if err := executeServiceCommand(openrcName, "start"); err != nil {
-					return fmt.Errorf("failed to start service: %w", err)
-				}
-			}
-
-			return nil
+			return enableJob{openrcName, runlevel}, nil
 		}
 	}
 
 	// If neither systemd nor OpenRC service exists, return an error
 	if !found && !openrcExists {
-		return fmt.Errorf("service file not found for %s", templateName)
+		return enableJob{}, fmt.Errorf("service file not found for %s", templateName)
 	}
 
 	// If systemd service file not found but OpenRC service exists, just enable the OpenRC service
 	if !found {
 		fmt.Printf("No systemd service file found for %s, but OpenRC service exists. Enabling existing service.\n", serviceName)
-
-		// Enable the service
-		if err := converter.EnableService(openrcName); err != nil {
-			return err
-		}
-
-		fmt.Printf("Service %s has been enabled\n", openrcName)
-
-		// Start the service if --now flag is provided
-		if nowFlag {
-			if err := executeServiceCommand(openrcName, "start"); err != nil {
-				return fmt.Errorf("failed to start service: %w", err)
-			}
-		}
-
-		return nil
+		return enableJob{openrcName, runlevel}, nil
 	} else {
-		// Parse the service file
-		config, err := parser.ParseServiceFile(serviceFile, instanceName)
-		if err != nil {
-			return fmt.Errorf("failed to parse service file: %w", err)
-		}
-
-		// Convert to OpenRC
-		script, err := converter.ConvertToOpenRC(config, openrcName, instanceName)
-		if err != nil {
-			return fmt.Errorf("failed to convert to OpenRC: %w", err)
-		}
-
-		// Write the OpenRC script
-		if err := converter.WriteOpenRCScript(script, openrcName); err != nil {
-			return fmt.Errorf("failed to write OpenRC script: %w", err)
+		if err := convertService(openrcName, serviceFile, instanceName); err != nil {
+			return enableJob{}, err
 		}
 
 		fmt.Printf("Service %s has been converted to OpenRC\n", openrcName)
 	}
 
-	// Enable the service
-	if err := converter.EnableService(openrcName); err != nil {
-		return err
-	}
+	return enableJob{openrcName, runlevel}, nil
+}
 
-	fmt.Printf("Service %s has been enabled\n", openrcName)
+// startIfRequested starts a service after enabling it when --now is given.
+// In offline mode there is nothing to start, so this only prints a notice.
+func startIfRequested(serviceName string) error {
+	if !nowFlag {
+		return nil
+	}
 
-	// Start the service if --now flag is provided
-	if nowFlag {
-		if err := executeServiceCommand(openrcName, "start"); err != nil {
-			return fmt.Errorf("failed to start service: %w", err)
-		}
+	if isOffline() {
+		fmt.Printf("Not starting %s: %v\n", serviceName, backend.ErrOffline)
+		return nil
 	}
 
-	return nil
+	return executeServiceCommand(serviceName, "start")
 }
 
 func init() {
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes used by systemctl, following systemd and the LSB init script
+// specification. The status-style codes apply to status and is-active, the
+// generic codes to every other command.
+const (
+	ExitSuccess = 0
+	ExitFailure = 1
+
+	// LSB codes for status queries
+	ExitProgramNotRunning = 3
+	ExitProgramUnknown    = 4
+
+	// LSB codes for actions
+	ExitInvalidArgument = 2
+	ExitNotImplemented  = 3
+	ExitNotInstalled    = 5
+	ExitNotConfigured   = 6
+)
+
+// ExitError is an error that carries the exit status of the process.
+// An ExitError without a wrapped error exits silently, which is how
+// query commands report a negative answer.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitWith wraps err so that the process exits with the given code
+func exitWith(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+// exitSilently makes the process exit with the given code without printing anything
+func exitSilently(code int) error {
+	if code == ExitSuccess {
+		return nil
+	}
+	return &ExitError{Code: code}
+}
+
+// UnitNotFoundError reports a unit that has no OpenRC script
+type UnitNotFoundError struct {
+	Name string
+}
+
+func (e *UnitNotFoundError) Error() string {
+	return fmt.Sprintf("service %s does not exist", e.Name)
+}
+
+// ExitCode returns the process exit status for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	// Job commands on unknown units exit like systemd's "Unit not found"
+	var notFound *UnitNotFoundError
+	if errors.As(err, &notFound) {
+		return ExitNotInstalled
+	}
+
+	return ExitFailure
+}
+
+// isSilentError reports whether an error should exit without a message
+func isSilentError(err error) bool {
+	var exitErr *ExitError
+	return errors.As(err, &exitErr) && exitErr.Err == nil
+}
@@ -2,41 +2,44 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-
-	"systemctl-alpine/pkg/util"
 
 	"github.com/spf13/cobra"
 )
 
 var isActiveCmd = &cobra.Command{
-	Use:   "is-active [service]",
-	Short: "Check if a service is currently active (running)",
-	Long: `Check if a service is currently active (running).
+	Use:   "is-active [service...]",
+	Short: "Check if one or more services are currently active (running)",
+	Long: `Check if one or more services are currently active (running).
 
-Prints the active state of the service to stdout (active, inactive, or failed).
-Returns exit code 0 if the service is active, non-zero otherwise.
+Prints the active state of each service on its own line (active, reloading,
+inactive, failed, activating or deactivating).
+Service names may be shell-style glob patterns, which are matched against known services.
+Returns exit code 0 if all services are active or reloading and 3 otherwise.
+Use -q/--quiet to suppress the output and only set the exit code.
 
 Example:
-  ` + cliName + ` is-active nginx`,
-	Args: cobra.ExactArgs(1),
+  ` + cliName + ` is-active nginx
+  ` + cliName + ` is-active --quiet nginx redis 'php-fpm@*'`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		serviceName := util.NormalizeServiceName(args[0])
-
-		if err := checkServiceExists(serviceName); err != nil {
-			return err
-		}
-
-		state, exitCode, err := getServiceState(serviceName)
+		services, err := expandServiceArgs(args)
 		if err != nil {
 			return err
 		}
 
-		fmt.Println(state)
+		allActive := len(services) > 0
+		for _, serviceName := range services {
+			state, _ := getUnitState(serviceName)
+			if !quietFlag {
+				fmt.Println(state.Active)
+			}
+			if !state.isActive() {
+				allActive = false
+			}
+		}
 
-		// Exit with the same code as rc-service for scriptability
-		if exitCode != 0 {
-			os.Exit(exitCode)
+		if !allActive {
+			return exitSilently(ExitProgramNotRunning)
 		}
 
 		return nil
@@ -46,4 +49,5 @@ Example:
 
 func init() {
 	rootCmd.AddCommand(isActiveCmd)
+	isActiveCmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress output, only set the exit code")
 }
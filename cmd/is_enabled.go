@@ -4,38 +4,62 @@ import (
 	"fmt"
 	"os"
 
-	"systemctl-alpine/pkg/util"
-
 	"github.com/spf13/cobra"
 )
 
 var isEnabledCmd = &cobra.Command{
-	Use:   "is-enabled [service]",
-	Short: "Check if a service is enabled to start at boot",
-	Long: `Check if a service is enabled to start at boot by examining the default runlevel.
+	Use:   "is-enabled [service...]",
+	Short: "Check if one or more services are enabled to start at boot",
+	Long: `Check if one or more services are enabled to start at boot by examining the
+OpenRC runlevels and the systemd unit files.
+
+Prints the enablement state of each service on its own line:
+  enabled         - Service is in an OpenRC runlevel
+  enabled-runtime - Service was started by hotplug for this boot only
+  alias           - Unit file or init script is a symlink to another service
+  indirect        - Template unit whose instances are enabled
+  generated       - Converted script whose systemd unit file has been removed
+  static          - Unit file has no [Install] section
+  masked          - Unit file or init script is linked to /dev/null
+  disabled        - Service exists but is not enabled, including OpenRC-only
+                    services in no runlevel
+
+Service names may be shell-style glob patterns, which are matched against known services.
+Returns exit code 0 if all services are enabled (enabled, enabled-runtime, alias,
+indirect or static) and 1 otherwise.
+Use -q/--quiet to suppress the output and only set the exit code.
 
 Example:
-  ` + cliName + ` is-enabled nginx`,
-	Args: cobra.ExactArgs(1),
+  ` + cliName + ` is-enabled nginx
+  ` + cliName + ` is-enabled --quiet nginx redis`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		serviceName := util.NormalizeServiceName(args[0])
-
-		if err := checkServiceExists(serviceName); err != nil {
+		services, err := expandServiceArgs(args)
+		if err != nil {
 			return err
 		}
 
-		isEnabled, err := isServiceEnabled(serviceName)
-		if err != nil {
-			return err
+		allEnabled := len(services) > 0
+		for _, serviceName := range services {
+			state, err := getUnitFileState(serviceName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get unit file state for %s.service: No such file or directory\n", serviceName)
+				allEnabled = false
+				continue
+			}
+
+			if !quietFlag {
+				fmt.Println(state)
+			}
+			if !isUnitFileStateEnabled(state) {
+				allEnabled = false
+			}
 		}
 
-		if isEnabled {
-			fmt.Println("enabled")
-			return nil
-		} else {
-			fmt.Println("disabled")
-			os.Exit(1)
+		if !allEnabled {
+			return exitSilently(ExitFailure)
 		}
+
 		return nil
 	},
 	SilenceUsage: true,
@@ -43,4 +67,5 @@ Example:
 
 func init() {
 	rootCmd.AddCommand(isEnabledCmd)
+	isEnabledCmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress output, only set the exit code")
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var isFailedCmd = &cobra.Command{
+	Use:   "is-failed [service...]",
+	Short: "Check if one or more services are in a failed state",
+	Long: `Check if one or more services are in a failed state (crashed in OpenRC terms).
+
+Prints the active state of each service on its own line (active, inactive, or failed).
+Service names may be shell-style glob patterns, which are matched against known services.
+Returns exit code 0 if any of the services has failed and 1 otherwise.
+Use -q/--quiet to suppress the output and only set the exit code.
+
+Example:
+  ` + cliName + ` is-failed nginx
+  ` + cliName + ` is-failed --quiet nginx redis`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		services, err := expandServiceArgs(args)
+		if err != nil {
+			return err
+		}
+
+		// Like systemd, one failed service is enough; with --quiet there
+		// is nothing to print for the others
+		anyFailed := false
+		for _, serviceName := range services {
+			state, _ := getUnitState(serviceName)
+			if state.Active == "failed" {
+				anyFailed = true
+			}
+			if quietFlag {
+				if anyFailed {
+					return nil
+				}
+				continue
+			}
+			fmt.Println(state.Active)
+		}
+
+		if !anyFailed {
+			return exitSilently(ExitFailure)
+		}
+
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(isFailedCmd)
+	isFailedCmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress output, only set the exit code")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// waitPollInterval is how often is-system-running --wait checks whether
+// boot has settled
+const waitPollInterval = 500 * time.Millisecond
+
+var waitFlag bool
+
+var isSystemRunningCmd = &cobra.Command{
+	Use:   "is-system-running",
+	Short: "Check whether the system is fully up and running",
+	Long: `Print the state of the system and check whether it is fully up and running.
+
+The state is derived from OpenRC's current runlevel, whether OpenRC is still
+changing runlevels and the number of failed services:
+
+  initializing  OpenRC is starting the sysinit or boot runlevel
+  starting      OpenRC is starting another runlevel, e.g. default
+  running       the runlevel has been reached and no service has failed
+  degraded      the runlevel has been reached but services have failed
+  maintenance   the system is in the single runlevel
+  stopping      the system is shutting down or changing runlevels
+  offline       OpenRC has not been booted, e.g. in a container build
+
+Returns exit code 0 if the system is running and 1 otherwise. With --wait, waits
+for the boot to settle (while the state is initializing or starting) first.
+Use -q/--quiet to suppress the output and only set the exit code.
+
+Example:
+  ` + cliName + ` is-system-running
+  ` + cliName + ` is-system-running --wait`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, _ := getSystemState()
+		for waitFlag && (state == "initializing" || state == "starting") {
+			time.Sleep(waitPollInterval)
+			state, _ = getSystemState()
+		}
+
+		if !quietFlag {
+			fmt.Println(state)
+		}
+		if state != "running" {
+			return exitSilently(ExitFailure)
+		}
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(isSystemRunningCmd)
+	isSystemRunningCmd.Flags().BoolVar(&waitFlag, "wait", false, "Wait until the boot has settled")
+	isSystemRunningCmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress output, only set the exit code")
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+
+	"systemctl-alpine/pkg/backend"
+	"systemctl-alpine/pkg/proc"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	killSignalFlag string
+	killWhomFlag   string
+)
+
+// Errors returned when there is nothing to signal
+var (
+	errNoMainProcess    = errors.New("no main process to kill")
+	errNoControlProcess = errors.New("no control process to kill")
+	errNoProcess        = errors.New("no process to kill")
+)
+
+var killCmd = &cobra.Command{
+	Use:   "kill [service...]",
+	Short: "Send a signal to the processes of a service",
+	Long: `Send a signal to the processes of one or more services.
+
+The signal is given by name (SIGUSR1, USR1 or usr1), as RTMIN+n or RTMAX-n, or
+by number, and defaults to SIGTERM. --kill-whom selects the processes:
+
+  main     the main process, from supervise-daemon or the service's pidfile
+  control  the supervise-daemon process of a supervised service
+  all      every process of the service (the default): the members of its
+           cgroup or, without one, of the main process's process group and
+           its descendants
+
+Example:
+  ` + cliName + ` kill --signal=SIGUSR1 --kill-whom=main nginx
+  ` + cliName + ` kill -s HUP rsyslog`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sig, err := proc.ParseSignal(killSignalFlag)
+		if err != nil {
+			return exitWith(ExitInvalidArgument, err)
+		}
+		switch killWhomFlag {
+		case "main", "control", "all":
+		default:
+			return exitWith(ExitInvalidArgument, fmt.Errorf("unknown value for --kill-whom %q (expected main, control or all)", killWhomFlag))
+		}
+
+		if isOffline() {
+			return exitWith(ExitFailure, backend.ErrOffline)
+		}
+
+		services, err := expandServiceArgs(args)
+		if err != nil {
+			return err
+		}
+
+		// Every unit is signalled even if an earlier one fails, as with
+		// forEachService, and the messages follow systemd's
+		exitCode := ExitSuccess
+		for _, serviceName := range services {
+			err := checkServiceExists(serviceName)
+			if err == nil {
+				err = killService(serviceName, sig, killWhomFlag)
+			}
+			if err == nil {
+				continue
+			}
+
+			var notFound *UnitNotFoundError
+			if errors.As(err, &notFound) {
+				fmt.Fprintf(os.Stderr, "Failed to kill unit %s.service: Unit %s.service not found.\n", serviceName, serviceName)
+			} else {
+				msg := err.Error()
+				fmt.Fprintf(os.Stderr, "Failed to kill unit %s.service: %s\n", serviceName, strings.ToUpper(msg[:1])+msg[1:])
+			}
+			if exitCode == ExitSuccess {
+				exitCode = ExitCode(err)
+			}
+		}
+
+		return exitSilently(exitCode)
+	},
+	SilenceUsage: true,
+}
+
+// killService sends a signal to the main process, the control process or
+// all processes of a service
+func killService(serviceName string, sig syscall.Signal, whom string) error {
+	config, _ := parseOpenRCScript(serviceName)
+	mainPID := getMainPID(serviceName, config)
+	controlPID := getControlPID(serviceName)
+
+	var pids []int
+	switch whom {
+	case "main":
+		if mainPID == 0 {
+			return errNoMainProcess
+		}
+		pids = []int{mainPID}
+	case "control":
+		if controlPID == 0 {
+			return errNoControlProcess
+		}
+		pids = []int{controlPID}
+	default:
+		pids = getKillPIDs(serviceName, mainPID, controlPID)
+		if len(pids) == 0 {
+			return errNoProcess
+		}
+	}
+
+	var errs []error
+	for _, pid := range pids {
+		// A process may exit before it is signalled
+		if err := syscall.Kill(pid, sig); err != nil && err != syscall.ESRCH {
+			errs = append(errs, fmt.Errorf("failed to send %s to process %d: %w", proc.SignalName(sig), pid, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// getControlPID returns the supervise-daemon process of a supervised
+// service, which stands in for systemd's control process. Returns 0 if the
+// service is not supervised or not running.
+func getControlPID(serviceName string) int {
+	state, err := backend.ReadServiceState(serviceName)
+	if err != nil {
+		return 0
+	}
+	return state.SupervisorPID
+}
+
+// getKillPIDs returns every process of a service: the members of its cgroup
+// or, when it has none, the process group of the main process and the
+// descendants of the main and control processes. The calling process is
+// never included.
+func getKillPIDs(serviceName string, mainPID, controlPID int) []int {
+	var pids []int
+	add := func(processes []*proc.Process) {
+		for _, p := range processes {
+			if p.PID != os.Getpid() && !slices.Contains(pids, p.PID) {
+				pids = append(pids, p.PID)
+			}
+		}
+	}
+
+	cgroup, processes := getServiceProcesses(serviceName, mainPID)
+	add(processes)
+	if cgroup != "" {
+		return pids
+	}
+
+	if mainPID > 0 {
+		// Daemons usually lead a process group of their own; a main process
+		// that shares its group with others is not one to signal as a whole
+		if p, err := proc.Get(mainPID); err == nil && p.PGID == mainPID {
+			group, _ := proc.Group(p.PGID)
+			add(group)
+		}
+	}
+	if controlPID > 0 {
+		descendants, _ := proc.Descendants(controlPID)
+		add(descendants)
+	}
+
+	slices.Sort(pids)
+	return pids
+}
+
+func init() {
+	rootCmd.AddCommand(killCmd)
+	killCmd.Flags().StringVarP(&killSignalFlag, "signal", "s", "SIGTERM", "Signal to send (name or number)")
+	killCmd.Flags().StringVar(&killWhomFlag, "kill-whom", "all", "Processes to signal (main, control or all)")
+}
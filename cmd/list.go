@@ -3,10 +3,12 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
+	"systemctl-alpine/pkg/paths"
+
 	"github.com/spf13/cobra"
 )
 
@@ -32,40 +34,14 @@ Example:
 		// Track services we've already seen to avoid duplicates
 		seenServices := make(map[string]bool)
 
-		// Map to store original systemd paths for services
-		systemdPaths := make(map[string]string)
-
-		// First, find all systemd service files and track their paths
-		for _, location := range serviceLocations {
-			// Check if directory exists
-			if _, err := os.Stat(location); os.IsNotExist(err) {
-				fmt.Printf("Directory %s does not exist, skipping\n", location)
-				continue
-			}
-
-			// Find all .service files
-			pattern := filepath.Join(location, "*.service")
-			files, err := filepath.Glob(pattern)
-			if err != nil {
-				return fmt.Errorf("failed to list service files in %s: %w", location, err)
-			}
-
-			// Process each service file
-			for _, file := range files {
-				// Extract service name from path
-				_, serviceName := filepath.Split(file)
-				serviceName = strings.TrimSuffix(serviceName, ".service")
-
-				// Store the systemd path for this service
-				systemdPaths[serviceName] = file
-
-				// Mark as seen
-				seenServices[serviceName] = true
-			}
+		// All systemd services, with the paths of their unit files
+		systemdPaths, _ := getSystemdServiceFiles()
+		for serviceName := range systemdPaths {
+			seenServices[serviceName] = true
 		}
 
 		// Now find all OpenRC services
-		openrcDir := "/etc/init.d"
+		openrcDir := paths.Resolve(paths.InitDir())
 		if _, err := os.Stat(openrcDir); err == nil {
 			files, err := os.ReadDir(openrcDir)
 			if err != nil {
@@ -95,11 +71,25 @@ Example:
 		}
 		sort.Strings(serviceNames)
 
+		// JSON output keeps the origin of a service in separate fields
+		output := &table{
+			Columns: []column{
+				{Header: "SERVICE", Key: "service", Shrink: true},
+				{Header: "STATUS", Key: "status", Shrink: true},
+			},
+			Footer: []string{fmt.Sprintf("%d services listed.", len(serviceNames))},
+		}
+		if isJSONOutput() {
+			output.Columns = append(output.Columns,
+				column{Key: "source"},
+				column{Key: "converted"},
+			)
+		}
+
 		// Now print all services
-		fmt.Printf("%-30s %s\n", "SERVICE", "STATUS")
 		for _, serviceName := range serviceNames {
 			// Check if service exists in OpenRC
-			openrcPath := filepath.Join("/etc/init.d", serviceName)
+			openrcPath := paths.InitScript(serviceName)
 			openrcExists := false
 			if _, err := os.Stat(openrcPath); err == nil {
 				openrcExists = true
@@ -111,20 +101,29 @@ Example:
 				status = "enabled"
 			}
 
+			originalPath := systemdPaths[serviceName]
+			if isJSONOutput() {
+				output.addRow(serviceName, status, originalPath, strconv.FormatBool(openrcExists))
+				continue
+			}
+
 			// Format output
 			if openrcExists {
 				// If we have a systemd path, show it's converted
-				if originalPath, exists := systemdPaths[serviceName]; exists {
-					fmt.Printf("%-30s %s (from %s)\n", serviceName, status, originalPath)
+				if originalPath != "" {
+					output.addRow(serviceName, fmt.Sprintf("%s (from %s)", status, originalPath))
 				} else {
-					fmt.Printf("%-30s %s\n", serviceName, status)
+					output.addRow(serviceName, status)
 				}
 			} else {
-				fmt.Printf("%-30s %s (not converted)\n", serviceName, status)
+				output.addRow(serviceName, status+" (not converted)")
 			}
 		}
 
-		return nil
+		w, done := startPager()
+		defer done()
+
+		return renderTable(w, output)
 	},
 	SilenceUsage: true,
 }
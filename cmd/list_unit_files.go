@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -10,65 +10,60 @@ import (
 )
 
 var (
-	typeFilter  string
-	stateFilter string
+	typeFilter       string
+	stateFilter      string
+	unitFilesAllFlag bool
 )
 
 var listUnitFilesCmd = &cobra.Command{
-	Use:   "list-unit-files",
+	Use:   "list-unit-files [pattern...]",
 	Short: "List all installed unit files and their enablement state",
 	Long: `List all installed systemd unit files and OpenRC services with their enablement state.
 
-The output shows two columns: UNIT FILE (name) and STATE (enabled/disabled/static).
+The output shows two columns: UNIT FILE (name) and STATE, using the same
+vocabulary as is-enabled.
 
 States:
   enabled  - Service is configured to start at boot
-  disabled - Service exists but is not configured to start at boot
-  static   - Service is OpenRC-only with no systemd unit file
+  disabled - Service exists but is not configured to start at boot, including
+             OpenRC-only services in no runlevel
+  static   - Service's unit file has no [Install] section
+  masked   - Service is linked to /dev/null
 
-Use --type and --state to filter the results.
+Patterns such as 'nginx.service' or 'php-fpm*' restrict the list to matching unit
+files; if none match, the exit code is 1. Use --type and --state to filter the
+results, and -o json for machine-readable output. Use --no-legend to omit the header
+and footer and --no-pager to disable paging. -a/--all is accepted for compatibility;
+every unit file is always listed.
 
 Example:
   ` + cliName + ` list-unit-files
   ` + cliName + ` list-unit-files --type=service
   ` + cliName + ` list-unit-files --state=enabled
-  ` + cliName + ` list-unit-files --type=service --state=enabled`,
-	Args: cobra.NoArgs,
+  ` + cliName + ` list-unit-files --type=service --state=enabled
+  ` + cliName + ` list-unit-files 'php-fpm*'
+  ` + cliName + ` list-unit-files -o json`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return listUnitFiles()
+		return listUnitFiles(args)
 	},
 	SilenceUsage: true,
 }
 
-func listUnitFiles() error {
+// listUnitFiles lists the unit files matching any of the patterns, or
+// all of them if there are none
+func listUnitFiles(patterns []string) error {
 	unitFiles := make(map[string]string) // serviceName -> state
 
-	// Get all systemd service files
-	systemdFiles, _ := getSystemdServiceFiles()
-	for serviceName := range systemdFiles {
-		isEnabled, _ := isServiceEnabled(serviceName)
-		state := "disabled"
-		if isEnabled {
-			state = "enabled"
-		}
-		unitFiles[serviceName] = state
-	}
-
-	// Add OpenRC-only services (static state)
-	openrcDir := "/etc/init.d"
-	if files, err := os.ReadDir(openrcDir); err == nil {
-		for _, file := range files {
-			// Skip directories and hidden files
-			if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
-				continue
-			}
-
-			serviceName := file.Name()
-
-			// Only add if not already in systemd files
-			if _, exists := systemdFiles[serviceName]; !exists {
-				unitFiles[serviceName] = "static"
-			}
+	// Get all systemd service files and OpenRC services, and their states
+	// in parallel
+	services, _ := getAllServices()
+	states := parallelMap(services, func(serviceName string) string {
+		state, _ := getUnitFileState(serviceName)
+		return state
+	})
+	for i, serviceName := range services {
+		if states[i] != "" {
+			unitFiles[serviceName] = states[i]
 		}
 	}
 
@@ -85,27 +80,67 @@ func listUnitFiles() error {
 			continue
 		}
 
+		matched, err := matchesUnitPatterns(serviceName+".service", patterns)
+		if err != nil {
+			return exitWith(ExitInvalidArgument, err)
+		}
+		if !matched {
+			continue
+		}
+
 		filteredFiles = append(filteredFiles, serviceName)
 	}
 
 	// Sort alphabetically
 	sort.Strings(filteredFiles)
 
-	// Print header
-	fmt.Printf("%-50s %s\n", "UNIT FILE", "STATE")
-
-	// Print each unit file
+	output := &table{
+		Columns: []column{
+			{Header: "UNIT FILE", Key: "unit_file", Shrink: true},
+			{Header: "STATE", Key: "state", Color: stateColor},
+		},
+		Footer: []string{fmt.Sprintf("%d unit files listed.", len(filteredFiles))},
+	}
 	for _, serviceName := range filteredFiles {
-		unitName := serviceName + ".service"
-		state := unitFiles[serviceName]
-		fmt.Printf("%-50s %s\n", unitName, state)
+		output.addRow(serviceName+".service", unitFiles[serviceName])
 	}
 
+	w, done := startPager()
+	defer done()
+
+	if err := renderTable(w, output); err != nil {
+		return err
+	}
+	if len(patterns) > 0 && len(filteredFiles) == 0 {
+		return exitSilently(ExitFailure)
+	}
 	return nil
 }
 
+// matchesUnitPatterns reports whether a unit file name matches any of the
+// shell-style patterns; names without a unit type match .service units
+func matchesUnitPatterns(unitName string, patterns []string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, ".") && !strings.HasSuffix(pattern, "*") {
+			pattern += ".service"
+		}
+		matched, err := filepath.Match(pattern, unitName)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func init() {
 	rootCmd.AddCommand(listUnitFilesCmd)
 	listUnitFilesCmd.Flags().StringVar(&typeFilter, "type", "", "Filter by unit type (service)")
-	listUnitFilesCmd.Flags().StringVar(&stateFilter, "state", "", "Filter by state (enabled, disabled, static)")
+	listUnitFilesCmd.Flags().StringVar(&stateFilter, "state", "", "Filter by state (enabled, disabled, static, masked, ...)")
+	listUnitFilesCmd.Flags().BoolVarP(&unitFilesAllFlag, "all", "a", false, "Accepted for compatibility; all unit files are always listed")
 }
@@ -11,6 +11,10 @@ var (
 	unitsAllFlag bool
 	unitsType    string
 	unitsState   string
+
+	// failedFlag is --failed, short for --state=failed, which may also be
+	// given without a command
+	failedFlag bool
 )
 
 var listUnitsCmd = &cobra.Command{
@@ -24,18 +28,25 @@ Use --all to show all units including disabled and inactive ones.
 The output shows five columns:
   UNIT     - The unit name
   LOAD     - Load state (loaded, not-found)
-  ACTIVE   - General activation state (active, inactive, failed)
-  SUB      - Low-level activation sub-state (running, dead, exited, failed)
+  ACTIVE   - General activation state (active, reloading, inactive, failed,
+             activating, deactivating)
+  SUB      - Low-level activation sub-state (running, exited, start, stop, dead,
+             failed, ...)
   DESCRIPTION - Unit description
 
-Use --all, --type, and --state to filter the results.
+Use --all, --type, and --state to filter the results (--failed is short for
+--state=failed, and '` + cliName + ` --failed' for 'list-units --failed'), and -o json for
+machine-readable output. Use --no-legend to omit the header and footer,
+--full to avoid ellipsizing columns and --no-pager to disable paging.
 
 Example:
   ` + cliName + ` list-units
   ` + cliName + ` list-units --all
   ` + cliName + ` list-units --type=service
   ` + cliName + ` list-units --state=active
-  ` + cliName + ` list-units -a --type=service`,
+  ` + cliName + ` --failed
+  ` + cliName + ` list-units -a --type=service
+  ` + cliName + ` list-units -o json-pretty`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return listUnits()
@@ -44,68 +55,63 @@ Example:
 }
 
 func listUnits() error {
+	if failedFlag {
+		unitsState = "failed"
+	}
+
 	allServices, err := getAllServices()
 	if err != nil {
 		return fmt.Errorf("failed to get services: %w", err)
 	}
 
-	// Get enabled services for default filtering
+	// Warm the shared lookups before the workers need them
 	enabledServices, _ := getEnabledServices()
+	systemdFiles, _ := getSystemdServiceFiles()
 
-	var unitsToShow []map[string]string
-
-	for _, serviceName := range allServices {
-		// Skip if service doesn't exist in /etc/init.d
+	// The state of every service is queried in parallel
+	units := parallelMap(allServices, func(serviceName string) map[string]string {
+		// Services without an OpenRC script are listed if they have a unit file
+		loadState := "loaded"
 		if err := checkServiceExists(serviceName); err != nil {
-			// Only include services from systemd files if --all is set
-			systemdFiles, _ := getSystemdServiceFiles()
 			if _, exists := systemdFiles[serviceName]; !exists {
-				continue
-			}
-		}
-
-		// Apply filters when --all is not specified
-		if !unitsAllFlag {
-			// Only show enabled or currently active services
-			isEnabled := enabledServices[serviceName]
-			state, _, _ := getServiceState(serviceName)
-			if !isEnabled && state != "active" {
-				continue
+				return nil
 			}
+			loadState = "not-found"
 		}
 
 		// Apply type filter (only service type supported)
 		if unitsType != "" && unitsType != "service" {
-			continue
+			return nil
 		}
 
-		// Get unit information
-		loadState := "loaded"
-		if err := checkServiceExists(serviceName); err != nil {
-			loadState = "not-found"
-		}
+		state, _ := getUnitState(serviceName)
+		activeState, subState := state.Active, state.Sub
 
-		activeState, _, _ := getServiceState(serviceName)
-		subState := getSubState(activeState)
-		description := getServiceDescription(serviceName)
+		// Only show enabled services and services that are not inactive when
+		// --all is not specified
+		if !unitsAllFlag && !enabledServices[serviceName] && activeState == "inactive" {
+			return nil
+		}
 
 		// Apply state filter
-		if unitsState != "" {
-			// Check if this state matches the filter
-			if !stateMatches(activeState, subState, unitsState) {
-				continue
-			}
+		if unitsState != "" && !stateMatches(activeState, subState, unitsState) {
+			return nil
 		}
 
-		unit := map[string]string{
+		return map[string]string{
 			"unit":        serviceName + ".service",
 			"load":        loadState,
 			"active":      activeState,
 			"sub":         subState,
-			"description": description,
+			"description": getServiceDescription(serviceName),
 		}
+	})
 
-		unitsToShow = append(unitsToShow, unit)
+	var unitsToShow []map[string]string
+	for _, unit := range units {
+		if unit != nil {
+			unitsToShow = append(unitsToShow, unit)
+		}
 	}
 
 	// Sort by unit name
@@ -113,21 +119,43 @@ func listUnits() error {
 		return unitsToShow[i]["unit"] < unitsToShow[j]["unit"]
 	})
 
-	// Print header
-	fmt.Printf("%-35s %-10s %-7s %-7s %s\n", "UNIT", "LOAD", "ACTIVE", "SUB", "DESCRIPTION")
-
-	// Print each unit
+	output := &table{
+		Columns: []column{
+			{Header: "UNIT", Key: "unit", Shrink: true},
+			{Header: "LOAD", Key: "load", Color: stateColor},
+			{Header: "ACTIVE", Key: "active", Color: stateColor},
+			{Header: "SUB", Key: "sub", Color: stateColor},
+			{Header: "DESCRIPTION", Key: "description", Shrink: true},
+		},
+		// Failed units are marked with a bullet like systemd does
+		Mark: func(row []string) string {
+			if row[1] != "loaded" || row[2] == "failed" {
+				return "●"
+			}
+			return ""
+		},
+	}
 	for _, unit := range unitsToShow {
-		fmt.Printf("%-35s %-10s %-7s %-7s %s\n",
-			unit["unit"],
-			unit["load"],
-			unit["active"],
-			unit["sub"],
-			unit["description"],
-		)
+		output.addRow(unit["unit"], unit["load"], unit["active"], unit["sub"], unit["description"])
+	}
+
+	output.Footer = []string{
+		"LOAD   = Reflects whether the unit definition was properly loaded.",
+		"ACTIVE = The high-level unit activation state, i.e. generalization of SUB.",
+		"SUB    = The low-level unit activation state, values depend on unit type.",
+		"",
+	}
+	listed := fmt.Sprintf("%d loaded units listed.", len(unitsToShow))
+	if !unitsAllFlag {
+		listed += " Pass --all to see loaded but inactive units, too."
 	}
+	output.Footer = append(output.Footer, listed,
+		"To show all installed unit files use '"+cliName+" list-unit-files'.")
+
+	w, done := startPager()
+	defer done()
 
-	return nil
+	return renderTable(w, output)
 }
 
 // stateMatches checks if the given active and sub states match the filter
@@ -159,4 +187,5 @@ func init() {
 	listUnitsCmd.Flags().BoolVarP(&unitsAllFlag, "all", "a", false, "Show all units including disabled and inactive ones")
 	listUnitsCmd.Flags().StringVar(&unitsType, "type", "", "Filter by unit type (service)")
 	listUnitsCmd.Flags().StringVar(&unitsState, "state", "", "Filter by active state (active, inactive, failed, running, dead, etc.)")
+	listUnitsCmd.Flags().BoolVar(&failedFlag, "failed", false, "Show only failed units (same as --state=failed)")
 }
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"systemctl-alpine/pkg/paths"
+)
+
+// logTailBytes bounds how much of a log file is read when tailing it
+const logTailBytes = 1 << 20
+
+// syslogFiles are searched for a service's messages when it has no log of
+// its own; they are resolved inside the root
+var syslogFiles = []string{
+	"/var/log/messages",
+	"/var/log/syslog",
+	"/var/log/daemon.log",
+}
+
+// getServiceLogLines returns the most recent log lines of a service.
+// Logs named in the init script (output_log, error_log) are used first,
+// then /var/log/<service>.log or the newest log in /var/log/<service>/,
+// and finally syslog lines tagged with the service name.
+func getServiceLogLines(serviceName string, config map[string]string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	var sources []string
+	for _, key := range []string{"output_log", "error_log"} {
+		if file := config[key]; file != "" {
+			file = paths.Resolve(expandScriptVars(file, serviceName, config))
+			if !slices.Contains(sources, file) {
+				sources = append(sources, file)
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		if file := filepath.Join(paths.Resolve(paths.LogDir), serviceName+".log"); fileExists(file) {
+			sources = append(sources, file)
+		} else if file := newestLogFile(filepath.Join(paths.Resolve(paths.LogDir), serviceName)); file != "" {
+			sources = append(sources, file)
+		}
+	}
+
+	var lines []string
+	for _, file := range sources {
+		lines = append(lines, tailLines(file, nil)...)
+	}
+
+	if len(lines) == 0 {
+		tagged := func(line string) bool {
+			return strings.Contains(line, " "+serviceName+"[") || strings.Contains(line, " "+serviceName+":")
+		}
+		for _, file := range syslogFiles {
+			if lines = tailLines(paths.Resolve(file), tagged); len(lines) > 0 {
+				break
+			}
+		}
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return lines
+}
+
+// tailLines reads the end of a file and returns its complete lines,
+// optionally keeping only those accepted by filter
+func tailLines(file string, filter func(string) bool) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil
+	}
+
+	offset := info.Size() - logTailBytes
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return nil
+		}
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	// Drop the partial first line when reading from the middle of the file
+	if offset > 0 && len(lines) > 0 {
+		lines = lines[1:]
+	}
+
+	var result []string
+	for _, line := range lines {
+		if line == "" || (filter != nil && !filter(line)) {
+			continue
+		}
+		result = append(result, line)
+	}
+
+	return result
+}
+
+// newestLogFile returns the most recently modified *.log file in a directory
+func newestLogFile(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+
+	sort.Slice(files, func(i, j int) bool {
+		a, errA := os.Stat(files[i])
+		b, errB := os.Stat(files[j])
+		if errA != nil || errB != nil {
+			return errB != nil
+		}
+		return a.ModTime().After(b.ModTime())
+	})
+
+	if len(files) == 0 {
+		return ""
+	}
+	return files[0]
+}
+
+// fileExists reports whether a regular file exists at path
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
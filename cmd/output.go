@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Output formats accepted by --output
+const (
+	outputPlain      = "plain"
+	outputJSON       = "json"
+	outputJSONPretty = "json-pretty"
+)
+
+var outputFlag string
+
+// column describes one column of a table: its header in text output and
+// its field name in JSON output
+type column struct {
+	Header string
+	Key    string
+	// Shrink allows the column to be ellipsized to fit the terminal
+	Shrink bool
+	// Color optionally picks a highlight color for a cell on terminals
+	Color func(value string) string
+}
+
+// table holds the rows of a listing command. The same rows are rendered as
+// aligned text or as a JSON array of objects keyed by the column keys.
+type table struct {
+	Columns []column
+	Rows    [][]string
+	// Footer lines are printed after a blank line unless --no-legend is set
+	Footer []string
+	// Mark optionally returns a bullet shown before a row on terminals
+	Mark func(row []string) string
+}
+
+// addRow appends a row; values are given in column order
+func (t *table) addRow(values ...string) {
+	t.Rows = append(t.Rows, values)
+}
+
+// jsonObject is a JSON object that keeps its keys in insertion order, so
+// that fields appear in the same order as the text columns
+type jsonObject struct {
+	Keys   []string
+	Values []any
+}
+
+func (o *jsonObject) set(key string, value any) {
+	o.Keys = append(o.Keys, key)
+	o.Values = append(o.Values, value)
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// validateOutputFlag checks the value of --output
+func validateOutputFlag() error {
+	switch outputFlag {
+	case outputPlain, outputJSON, outputJSONPretty:
+		return nil
+	}
+	return exitWith(ExitInvalidArgument, fmt.Errorf("unknown output format %q (expected plain, json or json-pretty)", outputFlag))
+}
+
+// isJSONOutput reports whether --output selected one of the JSON formats
+func isJSONOutput() bool {
+	return outputFlag == outputJSON || outputFlag == outputJSONPretty
+}
+
+// renderJSON writes v in the JSON format selected by --output
+func renderJSON(w io.Writer, v any) error {
+	var data []byte
+	var err error
+	if outputFlag == outputJSONPretty {
+		data, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON output: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// renderTable writes a table in the format selected by --output
+func renderTable(w io.Writer, t *table) error {
+	if isJSONOutput() {
+		objects := make([]jsonObject, 0, len(t.Rows))
+		for _, row := range t.Rows {
+			var object jsonObject
+			for i, col := range t.Columns {
+				object.set(col.Key, row[i])
+			}
+			objects = append(objects, object)
+		}
+		return renderJSON(w, objects)
+	}
+
+	terminal := isTerminal(os.Stdout)
+	marks := t.Mark != nil && terminal && !plainFlag
+
+	// Size each column to its widest cell; the last column is not padded
+	widths := make([]int, len(t.Columns))
+	for i, col := range t.Columns {
+		widths[i] = utf8.RuneCountInString(col.Header)
+	}
+	for _, row := range t.Rows {
+		for i, value := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(value))
+		}
+	}
+
+	// On terminals shrink columns from the right until the table fits,
+	// unless --full asks for complete values
+	if terminal && !fullFlag {
+		fitColumns(t.Columns, widths, terminalWidth(), marks)
+	}
+
+	writeRow := func(mark string, values []string, header bool) error {
+		var line strings.Builder
+		if marks {
+			if mark == "" {
+				line.WriteString("  ")
+			} else {
+				line.WriteString(colorize(ansiHighlightRed, mark) + " ")
+			}
+		}
+		for i, value := range values {
+			value = ellipsize(value, widths[i])
+			padding := ""
+			if i < len(values)-1 {
+				padding = strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value)+1)
+			}
+			if !header && t.Columns[i].Color != nil {
+				value = colorize(t.Columns[i].Color(value), value)
+			}
+			line.WriteString(value + padding)
+		}
+		_, err := fmt.Fprintln(w, line.String())
+		return err
+	}
+
+	if !noLegendFlag {
+		headers := make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			headers[i] = col.Header
+		}
+		if err := writeRow("", headers, true); err != nil {
+			return err
+		}
+	}
+	for _, row := range t.Rows {
+		mark := ""
+		if marks {
+			mark = t.Mark(row)
+		}
+		if err := writeRow(mark, row, false); err != nil {
+			return err
+		}
+	}
+
+	if !noLegendFlag && len(t.Footer) > 0 {
+		fmt.Fprintln(w)
+		for _, line := range t.Footer {
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	return nil
+}
+
+// minColumnWidth is the narrowest a shrinkable column is made
+const minColumnWidth = 10
+
+// fitColumns reduces the widths of shrinkable columns, last column first,
+// until the table fits into the given terminal width
+func fitColumns(columns []column, widths []int, width int, marks bool) {
+	total := len(widths) - 1
+	if marks {
+		total += 2
+	}
+	for _, w := range widths {
+		total += w
+	}
+
+	for i := len(columns) - 1; i >= 0 && total > width; i-- {
+		if !columns[i].Shrink || widths[i] <= minColumnWidth {
+			continue
+		}
+		reduce := min(total-width, widths[i]-minColumnWidth)
+		widths[i] -= reduce
+		total -= reduce
+	}
+}
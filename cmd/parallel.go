@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"sync"
+)
+
+// maxWorkers bounds the number of services that are queried or changed at
+// the same time. Most of the time goes to waiting for rc-service and
+// rc-update, so this does not depend on the number of CPUs.
+const maxWorkers = 8
+
+// parallelMap calls fn for every item, with at most maxWorkers calls running
+// at the same time, and returns the results in the order of the items
+func parallelMap[S, T any](items []S, fn func(S) T) []T {
+	results := make([]T, len(items))
+
+	workers := min(maxWorkers, len(items))
+	if workers <= 1 {
+		for i, item := range items {
+			results[i] = fn(item)
+		}
+		return results
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = fn(items[i])
+			}
+		}()
+	}
+
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
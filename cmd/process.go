@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"systemctl-alpine/pkg/backend"
+	"systemctl-alpine/pkg/proc"
+)
+
+// getMainPID resolves the main process of a running service.
+// The child pid recorded by supervise-daemon is preferred since the pidfile
+// then belongs to the supervisor; otherwise the pidfile named in the script
+// or in OpenRC's daemon state is used. Returns 0 if nothing is running.
+func getMainPID(serviceName string, config map[string]string) int {
+	state, _ := backend.ReadServiceState(serviceName)
+	if state != nil && state.ChildPID > 0 {
+		return state.ChildPID
+	}
+
+	var pidfiles []string
+	if pidfile, ok := config["pidfile"]; ok && pidfile != "" {
+		pidfiles = append(pidfiles, expandScriptVars(pidfile, serviceName, config))
+	}
+	if state != nil {
+		for _, daemon := range state.Daemons {
+			if daemon.Pidfile != "" {
+				pidfiles = append(pidfiles, daemon.Pidfile)
+			}
+		}
+	}
+
+	for _, pidfile := range pidfiles {
+		if pid, err := proc.ReadPidFile(pidfile); err == nil && proc.Alive(pid) {
+			return pid
+		}
+	}
+
+	return 0
+}
+
+// getServiceProcesses returns the control group of a service and the
+// processes that belong to it. When the service has no cgroup of its own
+// (cgroups disabled, or inside a container) the process tree below the
+// main pid is used instead.
+func getServiceProcesses(serviceName string, mainPID int) (string, []*proc.Process) {
+	cgroup := ""
+	if mainPID > 0 {
+		cgroup, _ = proc.CGroupOf(mainPID)
+	} else {
+		cgroup = "/openrc." + serviceName
+	}
+
+	// Only trust the cgroup if OpenRC created it for this service
+	base := path.Base(cgroup)
+	if base == "openrc."+serviceName || base == serviceName {
+		if pids, err := proc.CGroupProcs(cgroup); err == nil && len(pids) > 0 {
+			var processes []*proc.Process
+			for _, pid := range pids {
+				if p, err := proc.Get(pid); err == nil {
+					processes = append(processes, p)
+				}
+			}
+			return cgroup, processes
+		}
+	}
+
+	if mainPID == 0 {
+		return "", nil
+	}
+
+	processes, _ := proc.Descendants(mainPID)
+	return "", processes
+}
+
+// readKeyValueFile reads a file of key=value lines into a map
+func readKeyValueFile(file string) map[string]string {
+	values := make(map[string]string)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return values
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok {
+			values[key] = value
+		}
+	}
+
+	return values
+}
+
+// formatPID formats a pid with its command name, e.g. "1234 (nginx)"
+func formatPID(p *proc.Process) string {
+	return strconv.Itoa(p.PID) + " (" + p.Comm + ")"
+}
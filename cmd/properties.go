@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"systemctl-alpine/pkg/backend"
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/proc"
+	"systemctl-alpine/pkg/util"
+)
+
+// notSet is how systemd prints numeric properties without a value
+const notSet = "[not set]"
+
+// getServiceProperties gathers the properties `show` prints for a service,
+// named and formatted as systemd's. They are computed from the unit file,
+// the OpenRC script and its state, pidfiles, /proc and the service's
+// cgroup. Properties that do not apply are empty; show hides them unless
+// --all is given.
+func getServiceProperties(serviceName string) (map[string]string, error) {
+	properties := make(map[string]string)
+
+	id := serviceName + ".service"
+	properties["Id"] = id
+
+	unitPath, hasUnit := findUnitFile(serviceName)
+	hasScript := checkServiceExists(serviceName) == nil
+
+	unit := &parser.ServiceConfig{Unit: parser.UnitKeys{}}
+	if hasUnit {
+		_, instanceName := splitUnitName(serviceName)
+		if parsed, err := parser.ParseServiceFile(unitPath, instanceName); err == nil {
+			unit = parsed
+		}
+	}
+	config, _ := parseOpenRCScript(serviceName)
+
+	// Names are the unit itself, its aliases and the virtual services the
+	// script provides
+	names := []string{id}
+	names = append(names, serviceUnitNames(strings.Fields(unit.Alias))...)
+	names = append(names, serviceUnitNames(scriptDependencies(serviceName)["provide"])...)
+	properties["Names"] = strings.Join(uniqueStrings(names), " ")
+
+	switch {
+	case isServiceMasked(serviceName):
+		properties["LoadState"] = "masked"
+	case hasUnit || hasScript:
+		properties["LoadState"] = "loaded"
+	default:
+		properties["LoadState"] = "not-found"
+		properties["LoadError"] = fmt.Sprintf("org.freedesktop.systemd1.NoSuchUnit \"Unit %s not found.\"", id)
+	}
+
+	state, _ := getUnitState(serviceName)
+	properties["ActiveState"] = state.Active
+	properties["SubState"] = state.Sub
+
+	properties["Result"] = "success"
+	if state.Active == "failed" {
+		properties["Result"] = "exit-code"
+	}
+
+	// Numeric properties have a value even for units that do not exist, as
+	// in systemd, since scripts compare them, e.g. MainPID=0
+	properties["UnitFileState"] = ""
+	for _, key := range []string{"MainPID", "ExecMainPID", "ControlPID", "NRestarts"} {
+		properties[key] = "0"
+	}
+	for _, key := range []string{"MemoryCurrent", "TasksCurrent", "CPUUsageNSec"} {
+		properties[key] = notSet
+	}
+	properties["MemoryMax"], properties["TasksMax"] = "infinity", "infinity"
+	for _, key := range []string{"ExecMainStartTimestamp", "StateChangeTimestamp", "ActiveEnterTimestamp"} {
+		setTimestampProperty(properties, key, time.Time{})
+	}
+
+	if properties["LoadState"] == "not-found" {
+		properties["Description"] = id
+		return properties, nil
+	}
+
+	// Instances of templates that were not converted only have the unit's
+	// description, with the specifiers resolved
+	properties["Description"] = getServiceDescription(serviceName)
+	if properties["Description"] == "" {
+		properties["Description"] = unit.Description
+	}
+	properties["UnitFileState"], _ = getUnitFileState(serviceName)
+
+	// Paths are shown as seen from inside the root
+	switch {
+	case hasUnit:
+		properties["FragmentPath"] = paths.Unresolve(unitPath)
+	case hasScript:
+		properties["FragmentPath"] = paths.Unresolve(paths.InitScript(serviceName))
+	}
+	var dropIns []string
+	for _, dropIn := range unit.DropInPaths {
+		dropIns = append(dropIns, paths.Unresolve(dropIn))
+	}
+	properties["DropInPaths"] = strings.Join(dropIns, " ")
+	properties["NeedDaemonReload"] = "no"
+	if needsDaemonReload(serviceName) {
+		properties["NeedDaemonReload"] = "yes"
+	}
+
+	// Dependencies, from the unit file and the depend() function
+	depends := scriptDependencies(serviceName)
+	unitDeps := func(key string) []string {
+		return strings.Fields(strings.Join(unit.Unit.All("Unit", key), " "))
+	}
+	setList := func(key string, values ...[]string) {
+		properties[key] = strings.Join(uniqueStrings(slices.Concat(values...)), " ")
+	}
+	setList("Requires", unitDeps("Requires"), serviceUnitNames(depends["need"]))
+	setList("Wants", unitDeps("Wants"), serviceUnitNames(depends["use"]), serviceUnitNames(depends["want"]))
+	setList("BindsTo", unitDeps("BindsTo"))
+	setList("After", unitDeps("After"), serviceUnitNames(depends["need"]), serviceUnitNames(depends["use"]),
+		serviceUnitNames(depends["want"]), serviceUnitNames(depends["after"]))
+	setList("Before", unitDeps("Before"), serviceUnitNames(depends["before"]))
+	setList("RequiredBy", strings.Fields(unit.RequiredBy))
+	if unit.WantedBy != "" {
+		setList("WantedBy", strings.Fields(unit.WantedBy))
+	} else {
+		var targets []string
+		for _, runlevel := range getServiceRunlevels(serviceName) {
+			if target := runlevelTarget(runlevel); target != "" {
+				targets = append(targets, target)
+			}
+		}
+		setList("WantedBy", targets)
+	}
+
+	// Service settings
+	properties["Type"] = serviceType(unit, config)
+	properties["Restart"] = serviceRestart(unit, config)
+	properties["User"], properties["Group"] = unit.User, unit.Group
+	if user, group, _ := strings.Cut(config["command_user"], ":"); unit.User == "" && user != "" {
+		properties["User"], properties["Group"] = user, group
+	}
+	properties["WorkingDirectory"] = unit.WorkingDirectory
+	if unit.WorkingDirectory == "" {
+		properties["WorkingDirectory"] = config["directory"]
+	}
+	if pidfile := config["pidfile"]; pidfile != "" {
+		properties["PIDFile"] = paths.Unresolve(expandScriptVars(pidfile, serviceName, config))
+	}
+	properties["Environment"] = strings.Join(unit.Environment, " ")
+	properties["CanStart"] = "yes"
+	properties["CanStop"] = "yes"
+	properties["CanReload"] = "no"
+	if scriptSupportsReload(serviceName) {
+		properties["CanReload"] = "yes"
+	}
+
+	// Runtime state
+	openrcState, _ := backend.ReadServiceState(serviceName)
+
+	mainPID := getMainPID(serviceName, config)
+	var mainProcess *proc.Process
+	if mainPID > 0 {
+		mainProcess, _ = proc.Get(mainPID)
+	}
+	properties["MainPID"] = strconv.Itoa(mainPID)
+	properties["ExecMainPID"] = strconv.Itoa(mainPID)
+	properties["ControlPID"] = strconv.Itoa(getControlPID(serviceName))
+
+	var mainStart time.Time
+	if mainProcess != nil {
+		mainStart = mainProcess.StartTime
+	}
+	setTimestampProperty(properties, "ExecMainStartTimestamp", mainStart)
+
+	var since time.Time
+	if openrcState != nil {
+		since = openrcState.Since
+	}
+	setTimestampProperty(properties, "StateChangeTimestamp", since)
+	if !state.isActive() {
+		since = time.Time{}
+	} else if since.IsZero() {
+		since = mainStart
+	}
+	setTimestampProperty(properties, "ActiveEnterTimestamp", since)
+
+	properties["ExecStart"] = formatExecCommand(execStartArgv(serviceName, unit, config), mainStart, mainPID)
+
+	// OpenRC does not count the restarts done by supervise-daemon, so
+	// NRestarts stays 0
+
+	// Resource usage, from the cgroup accounting or summed over the processes
+	cgroup, processes := getServiceProcesses(serviceName, mainPID)
+	properties["ControlGroup"] = cgroup
+
+	var memory, tasks uint64
+	var cpu time.Duration
+	var haveMemory, haveTasks, haveCPU bool
+	if cgroup != "" {
+		memory, haveMemory = proc.CGroupMemory(cgroup)
+		tasks, haveTasks = proc.CGroupTasks(cgroup)
+		cpu, haveCPU = proc.CGroupCPUUsage(cgroup)
+		properties["MemoryMax"] = formatLimit(proc.CGroupLimit(cgroup, "memory.max"))
+		properties["TasksMax"] = formatLimit(proc.CGroupLimit(cgroup, "pids.max"))
+	}
+	for _, p := range processes {
+		if !haveMemory {
+			memory += p.RSS
+		}
+		if !haveTasks {
+			tasks += uint64(p.Threads)
+		}
+		if !haveCPU {
+			cpu += p.CPUTime
+		}
+	}
+	if len(processes) > 0 || cgroup != "" {
+		properties["MemoryCurrent"] = strconv.FormatUint(memory, 10)
+		properties["TasksCurrent"] = strconv.FormatUint(tasks, 10)
+		properties["CPUUsageNSec"] = strconv.FormatInt(cpu.Nanoseconds(), 10)
+	}
+
+	return properties, nil
+}
+
+// serviceUnitNames turns OpenRC service names into unit names, e.g. net
+// into net.service
+func serviceUnitNames(services []string) []string {
+	var names []string
+	for _, service := range services {
+		if !strings.Contains(service, ".") {
+			service += ".service"
+		}
+		names = append(names, service)
+	}
+	return names
+}
+
+// uniqueStrings drops repeated values, keeping the first of each
+func uniqueStrings(values []string) []string {
+	var unique []string
+	for _, value := range values {
+		if !slices.Contains(unique, value) {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// runlevelTarget returns the systemd target that a runlevel stands for,
+// preferring multi-user.target for the default runlevel
+func runlevelTarget(runlevel string) string {
+	if cfg.Runlevels["multi-user.target"] == runlevel {
+		return "multi-user.target"
+	}
+
+	var targets []string
+	for target, level := range cfg.Runlevels {
+		if level == runlevel && target != "default.target" {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return ""
+	}
+	slices.Sort(targets)
+	return targets[0]
+}
+
+// serviceType returns the Type= of a service: the unit's or, for OpenRC
+// scripts, one derived from how the daemon is started
+func serviceType(unit *parser.ServiceConfig, config map[string]string) string {
+	switch {
+	case unit.Type != "":
+		return unit.Type
+	case config["command"] == "":
+		return "oneshot"
+	case config["command_background"] == "true" || config["command_background"] == "yes",
+		config["supervisor"] == "supervise-daemon":
+		return "simple"
+	}
+	return "forking"
+}
+
+// serviceRestart returns the Restart= of a service; supervise-daemon
+// restarts a daemon whenever it exits
+func serviceRestart(unit *parser.ServiceConfig, config map[string]string) string {
+	switch {
+	case unit.Restart != "":
+		return unit.Restart
+	case config["supervisor"] == "supervise-daemon":
+		return "always"
+	}
+	return "no"
+}
+
+// execStartArgv returns the command line of a service's main process: the
+// unit's ExecStart= or the script's command and command_args
+func execStartArgv(serviceName string, unit *parser.ServiceConfig, config map[string]string) []string {
+	if unit.ExecStart != "" {
+		// Drop the prefixes that change how systemd runs the command
+		return strings.Fields(strings.TrimLeft(unit.ExecStart, "-@:+!"))
+	}
+	if config["command"] == "" {
+		return nil
+	}
+	argv := []string{expandScriptVars(config["command"], serviceName, config)}
+	return append(argv, strings.Fields(expandScriptVars(config["command_args"], serviceName, config))...)
+}
+
+// formatExecCommand formats a command the way systemd shows ExecStart=
+func formatExecCommand(argv []string, start time.Time, pid int) string {
+	if len(argv) == 0 {
+		return ""
+	}
+
+	startTime := "n/a"
+	if !start.IsZero() {
+		startTime = util.FormatTimestamp(start)
+	}
+	return fmt.Sprintf("{ path=%s ; argv[]=%s ; ignore_errors=no ; start_time=[%s] ; stop_time=[n/a] ; pid=%d ; code=(null) ; status=0/0 }",
+		argv[0], strings.Join(argv, " "), startTime, pid)
+}
+
+// setTimestampProperty sets a timestamp property and its Monotonic
+// counterpart, the microseconds since boot. Unset timestamps are empty
+// and 0.
+func setTimestampProperty(properties map[string]string, key string, t time.Time) {
+	properties[key], properties[key+"Monotonic"] = "", "0"
+	if t.IsZero() {
+		return
+	}
+	properties[key] = util.FormatTimestamp(t)
+	if boot, err := proc.BootTime(); err == nil && t.After(boot) {
+		properties[key+"Monotonic"] = strconv.FormatInt(t.Sub(boot).Microseconds(), 10)
+	}
+}
+
+// formatLimit formats a resource limit, which is infinity when unlimited
+// or unknown
+func formatLimit(limit uint64, ok bool) string {
+	if !ok || limit == math.MaxUint64 {
+		return "infinity"
+	}
+	return strconv.FormatUint(limit, 10)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var reloadOrRestartCmd = &cobra.Command{
+	Use:   "reload-or-restart [service...]",
+	Short: "Reload one or more services if they support it, restart them otherwise",
+	Long: `Reload one or more services if their OpenRC scripts support reload, and
+restart them otherwise. Services that are not running are started.
+
+A script supports reload if it defines a reload function and lists reload in
+extra_started_commands (or extra_commands), which OpenRC requires to run it.
+
+Example:
+  ` + cliName + ` reload-or-restart nginx`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachService(args, "reload-or-restart", func(serviceName string) error {
+			return conditionalRestart(serviceName, false, true)
+		})
+	},
+	SilenceUsage: true,
+}
+
+var tryReloadOrRestartCmd = &cobra.Command{
+	Use:     "try-reload-or-restart [service...]",
+	Aliases: []string{"force-reload", "reload-or-try-restart"},
+	Short:   "Reload or restart one or more services if they are running",
+	Long: `Reload one or more services if they are running and their OpenRC scripts
+support reload, and restart them if they are running but do not support it.
+Services that are not running are left alone, as with systemd.
+
+This is what logrotate and package hooks usually call.
+
+Example:
+  ` + cliName + ` try-reload-or-restart nginx`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachService(args, "try-reload-or-restart", func(serviceName string) error {
+			return conditionalRestart(serviceName, true, true)
+		})
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(reloadOrRestartCmd)
+	rootCmd.AddCommand(tryReloadOrRestartCmd)
+}
@@ -1,27 +1,21 @@
 package cmd
 
 import (
-	"systemctl-alpine/pkg/util"
-
 	"github.com/spf13/cobra"
 )
 
 var restartCmd = &cobra.Command{
-	Use:   "restart [service]",
-	Short: "Restart a service",
-	Long: `Restart a service using OpenRC's rc-service command.
+	Use:   "restart [service...]",
+	Short: "Restart one or more services",
+	Long: `Restart one or more services using OpenRC's rc-service command, after
+the services they depend on.
 
 Example:
-  ` + cliName + ` restart nginx`,
-	Args: cobra.ExactArgs(1),
+  ` + cliName + ` restart nginx
+  ` + cliName + ` restart 'php-fpm@*'`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		serviceName := util.NormalizeServiceName(args[0])
-
-		if err := checkServiceExists(serviceName); err != nil {
-			return err
-		}
-
-		return executeServiceCommand(serviceName, "restart")
+		return runServiceCommand(args, "restart")
 	},
 	SilenceUsage: true,
 }
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/util"
+
+	"github.com/spf13/cobra"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert [service...]",
+	Short: "Revert services to their vendor versions",
+	Long: `Revert one or more services to the vendor version of their unit files.
+
+This undoes 'edit' and masking: drop-ins in /etc/systemd/system/<unit>.d and
+/run/systemd/system/<unit>.d are removed, as are copies of unit files in those
+directories that override a vendor unit file, and /dev/null mask symlinks. The
+OpenRC script is then generated again from the vendor unit file, which also
+removes the "# Modified by systemctl edit" marker.
+
+Every file that is removed is reported.
+
+Example:
+  ` + cliName + ` revert nginx`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, arg := range args {
+			if err := revertService(util.NormalizeServiceName(arg)); err != nil {
+				return exitWith(ExitFailure, fmt.Errorf("failed to revert %s: %w", arg, err))
+			}
+		}
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+// revertService removes the administrator's changes to a service and
+// generates its OpenRC script again from the vendor unit file
+func revertService(serviceName string) error {
+	unitFileName, _ := splitUnitName(serviceName)
+	dropInDir := serviceName + ".service.d"
+
+	changed := false
+	for _, dir := range []string{paths.AdminUnitDir, paths.RuntimeUnitDir} {
+		dir = paths.Resolve(dir)
+
+		// Drop-ins of the unit
+		dropIns, _ := filepath.Glob(filepath.Join(dir, dropInDir, "*.conf"))
+		for _, dropIn := range dropIns {
+			if err := removeReported(dropIn); err != nil {
+				return err
+			}
+			changed = true
+		}
+		// The directory is kept if it has other files
+		os.Remove(filepath.Join(dir, dropInDir))
+
+		// Masks, and copies of vendor unit files made by edit --full
+		unitFile := filepath.Join(dir, unitFileName)
+		if isMaskLink(unitFile) || hasVendorUnitFile(unitFileName) && isRegularFile(unitFile) {
+			if err := removeReported(unitFile); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		resetLookups()
+	}
+
+	scriptPath := paths.InitScript(serviceName)
+	maskedScript := isMaskLink(scriptPath)
+	if !changed && !maskedScript && !isModifiedScript(serviceName) {
+		return nil
+	}
+
+	unitFile, found := findUnitFile(serviceName)
+	if !found {
+		if maskedScript || isModifiedScript(serviceName) {
+			fmt.Printf("Keeping %s: there is no unit file to generate it from\n", paths.Unresolve(scriptPath))
+		}
+		return nil
+	}
+
+	// Only services that have been converted, or whose script is masked,
+	// get a script; revert does not enable anything new
+	if _, err := os.Lstat(scriptPath); err != nil {
+		return nil
+	}
+	if maskedScript {
+		if err := removeReported(scriptPath); err != nil {
+			return err
+		}
+	}
+
+	_, instanceName := splitUnitName(serviceName)
+	if err := convertService(serviceName, unitFile, instanceName); err != nil {
+		return err
+	}
+
+	fmt.Printf("Generated %s from %s\n", paths.Unresolve(scriptPath), paths.Unresolve(unitFile))
+	return nil
+}
+
+// removeReported removes a file and reports it like systemd does
+func removeReported(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	fmt.Printf("Removed \"%s\".\n", paths.Unresolve(path))
+	return nil
+}
+
+// isMaskLink reports whether path is a symlink to /dev/null
+func isMaskLink(path string) bool {
+	target, err := os.Readlink(path)
+	return err == nil && target == "/dev/null"
+}
+
+// isRegularFile reports whether path is a regular file (not a symlink)
+func isRegularFile(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// hasVendorUnitFile reports whether a unit file exists in a unit directory
+// other than those of the administrator, so that removing the
+// administrator's copy falls back to it
+func hasVendorUnitFile(unitFileName string) bool {
+	admin := []string{paths.Resolve(paths.AdminUnitDir), paths.Resolve(paths.RuntimeUnitDir)}
+
+	for _, location := range paths.UnitDirs() {
+		if slices.Contains(admin, location) {
+			continue
+		}
+		path := filepath.Join(location, unitFileName)
+		if _, err := os.Stat(path); err == nil && !isMaskLink(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+}
@@ -1,6 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"systemctl-alpine/pkg/backend"
+	"systemctl-alpine/pkg/config"
+	"systemctl-alpine/pkg/converter"
+	"systemctl-alpine/pkg/paths"
+
 	"github.com/spf13/cobra"
 )
 
@@ -10,15 +18,25 @@ var (
 	// Version is the version string, embedded at build time
 	Version = "dev"
 
-	// Locations to search for systemd service files
-	serviceLocations = []string{
-		"/etc/systemd/system",
-		"/lib/systemd/system",
-	}
+	rootFlag string
+
+	// versionFlag prints the version, like the version command
+	versionFlag bool
+
+	// noBlockFlag is accepted for compatibility: OpenRC has no job queue,
+	// so commands always wait for rc-service to finish
+	noBlockFlag bool
 
 	nowFlag   bool
 	allFlag   bool
 	forceFlag bool
+	quietFlag bool
+
+	// manager controls services; tests replace it with a fake
+	manager backend.ServiceManager = backend.NewOpenRC()
+
+	// cfg is the configuration loaded from the config file
+	cfg = config.Default()
 )
 
 var rootCmd = &cobra.Command{
@@ -29,21 +47,103 @@ by translating systemctl commands to their OpenRC equivalents.
 
 For example, you can use '` + cliName + ` enable some-service' to convert a systemd
 service file to an OpenRC init script and enable it to start at boot.`,
-	Version: getVersion(),
+	// Errors are printed by Execute so that exit codes can be silent
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFlag(); err != nil {
+			return err
+		}
+		if err := setupRoot(); err != nil {
+			return err
+		}
+		return loadConfig()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case versionFlag:
+			printVersion()
+			return nil
+		case failedFlag:
+			return listUnits()
+		}
+		return cmd.Help()
+	},
+	SilenceUsage: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
+// The returned error can be turned into a process exit status with ExitCode.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if err != nil && !isSilentError(err) {
+		rootCmd.PrintErrln(rootCmd.ErrPrefix(), err.Error())
+	}
+	return err
+}
+
+// setupRoot applies --root and switches to the offline service manager when
+// operating on a root directory or when OpenRC has not been booted, so that
+// runlevel symlinks are managed directly instead of through rc-update
+func setupRoot() error {
+	if rootFlag != "" {
+		if err := paths.SetRoot(rootFlag); err != nil {
+			return exitWith(ExitInvalidArgument, fmt.Errorf("invalid root directory %q: %w", rootFlag, err))
+		}
+	}
+
+	if _, ok := manager.(*backend.OpenRC); ok && (paths.IsRooted() || !backend.Booted()) {
+		manager = backend.NewOffline()
+	}
+
+	return nil
+}
+
+// loadConfig reads the configuration file named by $SYSTEMCTL_ALPINE_CONFIG,
+// or the default one inside the root directory, and applies it
+func loadConfig() error {
+	path, ok := os.LookupEnv(config.EnvPath)
+	if !ok {
+		path = paths.Resolve(config.DefaultPath)
+	}
+
+	loaded, err := config.Load(path)
+	if err != nil {
+		return exitWith(ExitNotConfigured, err)
+	}
+	cfg = loaded
+
+	paths.SetUnitDirs(cfg.UnitPaths)
+	paths.SetInitDir(cfg.Converter.InitDir)
+	paths.SetConfDir(cfg.Converter.ConfDir)
+	converter.Defaults.Depend = cfg.Converter.Depend
+	converter.Defaults.Supervisor = cfg.Converter.Supervisor
+	converter.Defaults.TemplateDir = cfg.Converter.TemplateDir
+
+	return nil
+}
+
+// isOffline reports whether services are managed without a running OpenRC
+func isOffline() bool {
+	_, ok := manager.(*backend.Offline)
+	return ok
 }
 
-// getVersion returns the version string for this application
-func getVersion() string {
-	// This will be populated from version.go
-	return Version
+// SetServiceManager replaces the service manager used by all commands,
+// e.g. with backend.NewFake() to run commands without OpenRC
+func SetServiceManager(m backend.ServiceManager) {
+	manager = m
 }
 
 func init() {
 	// Here you will define your flags and configuration settings
 	rootCmd.CompletionOptions.DisableDefaultCmd = false
+	rootCmd.PersistentFlags().StringVar(&rootFlag, "root", "", "Operate on the given root directory instead of the host (offline mode)")
+	rootCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", outputPlain, "Output format (plain, json, json-pretty)")
+	rootCmd.PersistentFlags().BoolVar(&noLegendFlag, "no-legend", false, "Do not print the legend (column headers and hints)")
+	rootCmd.PersistentFlags().BoolVar(&noPagerFlag, "no-pager", false, "Do not pipe output into a pager")
+	rootCmd.PersistentFlags().BoolVar(&plainFlag, "plain", false, "Print plain output without bullets or colors")
+	rootCmd.PersistentFlags().BoolVarP(&fullFlag, "full", "l", false, "Do not ellipsize output to fit the terminal")
+	rootCmd.Flags().BoolVarP(&versionFlag, "version", "v", false, "Show version information")
+	rootCmd.Flags().BoolVar(&failedFlag, "failed", false, "List failed units (same as list-units --state=failed)")
+	rootCmd.PersistentFlags().BoolVar(&noBlockFlag, "no-block", false, "Accepted for compatibility; commands always wait for OpenRC to finish")
 }
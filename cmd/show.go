@@ -2,76 +2,175 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
-	"systemctl-alpine/pkg/util"
+	"systemctl-alpine/pkg/proc"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	propertyFlags []string
-	valueOnlyFlag bool
+	propertyFlags      []string
+	valuePropertyFlags []string
+	valueOnlyFlag      bool
+	showAllFlag        bool
 )
 
 var showCmd = &cobra.Command{
-	Use:   "show [service]",
-	Short: "Show properties of a service or the service manager",
-	Long: `Show low-level properties of a service in key=value format.
-
-If no service is specified, shows properties of the systemd manager itself.
-
-Use -p/--property to show specific properties, and --value to show only values.
+	Use:   "show [service...]",
+	Short: "Show properties of services or the service manager",
+	Long: `Show low-level properties of one or more services in key=value format.
+
+The properties of several services are printed as blocks separated by blank
+lines. Service names may be shell-style glob patterns, and instances of template
+units are shown with their specifiers resolved. If no service is specified,
+shows properties of the systemd manager itself.
+
+Properties use systemd's names and value formats: timestamps such as
+ActiveEnterTimestamp, MainPID, FragmentPath, Requires, After, WantedBy, Restart,
+MemoryCurrent, CPUUsageNSec, Result and LoadError. They are computed from the unit
+file, the OpenRC script and state, pidfiles, /proc and the service's cgroup.
+
+Use -p/--property to show specific properties, given as a comma-separated list or
+with repeated options, and --value to show only values. -P NAME is short for
+--property=NAME --value; its long form --property-value is not in systemd.
+Requested properties are always printed, empty if the service has no such
+property; other empty properties are not shown unless -a/--all is given.
+Use -o json to print the properties as a JSON object, or as an array of objects
+when several services or a pattern are given.
 
 Example:
   ` + cliName + ` show nginx
   ` + cliName + ` show nginx -p ActiveState
   ` + cliName + ` show nginx --property=ActiveState --property=UnitFileState
-  ` + cliName + ` show nginx --property=ActiveState --value`,
-	Args: cobra.MaximumNArgs(1),
+  ` + cliName + ` show nginx --property=ActiveState --value
+  ` + cliName + ` show nginx redis -p ActiveState,SubState,MainPID
+  ` + cliName + ` show -P MainPID nginx`,
+	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(valuePropertyFlags) > 0 {
+			propertyFlags = append(propertyFlags, valuePropertyFlags...)
+			valueOnlyFlag = true
+		}
+
 		if len(args) == 0 {
 			// Show manager properties when no service specified
 			return showManagerProperties()
 		}
 
-		serviceName := util.NormalizeServiceName(args[0])
-
-		if err := checkServiceExists(serviceName); err != nil {
+		// Unknown units are shown with LoadState=not-found, as systemd does
+		services, err := expandServiceArgs(args)
+		if err != nil {
 			return err
 		}
 
-		properties, err := getServiceProperties(serviceName)
-		if err != nil {
-			return fmt.Errorf("failed to get service properties: %w", err)
+		objects := []jsonObject{}
+		for i, serviceName := range services {
+			properties, err := getServiceProperties(serviceName)
+			if err != nil {
+				return fmt.Errorf("failed to get service properties: %w", err)
+			}
+
+			if isJSONOutput() {
+				objects = append(objects, showOutputObject(properties, propertyFlags, showAllFlag))
+				continue
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(formatShowOutput(properties, propertyFlags, valueOnlyFlag, showAllFlag))
 		}
 
-		output := formatShowOutput(properties, propertyFlags, valueOnlyFlag)
-		fmt.Print(output)
-
+		if isJSONOutput() {
+			// A single service is printed as an object. Several services
+			// or a pattern give an array, even if it matched one service
+			// or none, so that its shape does not depend on the system.
+			if len(args) == 1 && !isServicePattern(args[0]) {
+				return renderJSON(os.Stdout, objects[0])
+			}
+			return renderJSON(os.Stdout, objects)
+		}
 		return nil
 	},
 	SilenceUsage: true,
 }
 
-// showManagerProperties displays properties of the systemd manager itself
+// showManagerProperties displays properties of the service manager, as
+// systemd's manager properties derived from OpenRC
 func showManagerProperties() error {
 	properties := make(map[string]string)
 
-	// Basic manager properties
-	properties["Version"] = "230" // Fake version (Alpine uses OpenRC)
-	properties["Architecture"] = runtime.GOARCH
+	properties["Version"] = strconv.FormatInt(cfg.SystemdVersion, 10)
+	properties["Architecture"] = systemdArchitecture(runtime.GOARCH)
 	properties["DefaultStandardOutput"] = "stdout"
 	properties["DefaultStandardError"] = "inherit"
 
-	output := formatShowOutput(properties, propertyFlags, valueOnlyFlag)
-	fmt.Print(output)
+	state, failed := getSystemState()
+	properties["SystemState"] = state
+	properties["NFailedUnits"] = strconv.Itoa(len(failed))
+	properties["NJobs"] = "0"
+
+	services, _ := getAllServices()
+	properties["NNames"] = strconv.Itoa(len(services))
+	properties["UnitPath"] = strings.Join(cfg.UnitPaths, " ")
+	properties["DefaultTarget"] = runlevelTarget(cfg.DefaultRunlevel)
+
+	// The environment of OpenRC is that of init
+	properties["Environment"] = ""
+	if environ, err := os.ReadFile("/proc/1/environ"); err == nil {
+		properties["Environment"] = strings.Join(strings.FieldsFunc(string(environ), func(r rune) bool { return r == 0 }), " ")
+	}
+
+	var kernel, userspace time.Time
+	if boot, err := proc.BootTime(); err == nil {
+		kernel = boot
+	}
+	if init, err := proc.Get(1); err == nil {
+		userspace = init.StartTime
+	}
+	setTimestampProperty(properties, "KernelTimestamp", kernel)
+	setTimestampProperty(properties, "UserspaceTimestamp", userspace)
+
+	return printShowOutput(properties)
+}
+
+// systemdArchitecture returns systemd's name for a Go architecture
+func systemdArchitecture(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x86-64"
+	case "386":
+		return "x86"
+	case "ppc64le":
+		return "ppc64-le"
+	case "mips64le":
+		return "mips64-le"
+	case "mipsle":
+		return "mips-le"
+	case "loong64":
+		return "loongarch64"
+	}
+	return goarch
+}
+
+// printShowOutput prints properties in the format selected by --output
+func printShowOutput(properties map[string]string) error {
+	if isJSONOutput() {
+		return renderJSON(os.Stdout, showOutputObject(properties, propertyFlags, showAllFlag))
+	}
 
+	fmt.Print(formatShowOutput(properties, propertyFlags, valueOnlyFlag, showAllFlag))
 	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(showCmd)
-	showCmd.Flags().StringSliceVarP(&propertyFlags, "property", "p", []string{}, "Show specific properties (can be used multiple times)")
+	showCmd.Flags().StringSliceVarP(&propertyFlags, "property", "p", []string{}, "Show specific properties (comma-separated, can be used multiple times)")
+	showCmd.Flags().StringSliceVarP(&valuePropertyFlags, "property-value", "P", []string{}, "Show the values of specific properties (same as --property=NAME --value; the long form is not in systemd)")
 	showCmd.Flags().BoolVar(&valueOnlyFlag, "value", false, "Show only values, not keys")
+	showCmd.Flags().BoolVarP(&showAllFlag, "all", "a", false, "Show all properties, including empty ones")
 }
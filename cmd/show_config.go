@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var showConfigCmd = &cobra.Command{
+	Use:   "show-config",
+	Short: "Show the effective configuration",
+	Long: `Show the effective configuration: unit file search paths, the mapping of
+systemd targets to OpenRC runlevels, the emulated systemd version and the
+converter defaults.
+
+The configuration is read from /etc/systemctl-alpine/config.toml, or from the
+file named by the SYSTEMCTL_ALPINE_CONFIG environment variable. Settings that
+are not in the file keep their built-in defaults.
+
+Example:
+  ` + cliName + ` show-config
+  ` + cliName + ` show-config -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isJSONOutput() {
+			fmt.Print(cfg.Format())
+			return nil
+		}
+
+		var converterObject jsonObject
+		converterObject.set("depend", cfg.Converter.Depend)
+		converterObject.set("supervisor", cfg.Converter.Supervisor)
+		converterObject.set("init_dir", cfg.Converter.InitDir)
+		converterObject.set("conf_dir", cfg.Converter.ConfDir)
+		converterObject.set("template_dir", cfg.Converter.TemplateDir)
+
+		var object jsonObject
+		object.set("path", cfg.Path)
+		object.set("unit_paths", cfg.UnitPaths)
+		object.set("default_runlevel", cfg.DefaultRunlevel)
+		object.set("systemd_version", cfg.SystemdVersion)
+		object.set("runlevels", cfg.Runlevels)
+		object.set("converter", converterObject)
+
+		return renderJSON(os.Stdout, object)
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(showConfigCmd)
+}
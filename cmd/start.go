@@ -1,27 +1,22 @@
 package cmd
 
 import (
-	"systemctl-alpine/pkg/util"
-
 	"github.com/spf13/cobra"
 )
 
 var startCmd = &cobra.Command{
-	Use:   "start [service]",
-	Short: "Start a service",
-	Long: `Start a service using OpenRC's rc-service command.
+	Use:   "start [service...]",
+	Short: "Start one or more services",
+	Long: `Start one or more services using OpenRC's rc-service command, after the
+services they depend on.
 
 Example:
-  ` + cliName + ` start nginx`,
-	Args: cobra.ExactArgs(1),
+  ` + cliName + ` start nginx
+  ` + cliName + ` start postgresql nginx
+  ` + cliName + ` start 'php-fpm@*'`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		serviceName := util.NormalizeServiceName(args[0])
-
-		if err := checkServiceExists(serviceName); err != nil {
-			return err
-		}
-
-		return executeServiceCommand(serviceName, "start")
+		return runServiceCommand(args, "start")
 	},
 	SilenceUsage: true,
 }
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"systemctl-alpine/pkg/backend"
+)
+
+// unitState is the state of a service in systemd's terms
+type unitState struct {
+	// Active is the ActiveState: active, reloading, inactive, failed,
+	// activating or deactivating
+	Active string
+	// Sub is the SubState, e.g. running, exited, start-pre, auto-restart
+	// or dead
+	Sub string
+}
+
+// The states a service can be in
+var (
+	stateRunning     = unitState{"active", "running"}
+	stateExited      = unitState{"active", "exited"}
+	stateReloading   = unitState{"reloading", "reload"}
+	stateStartPre    = unitState{"activating", "start-pre"}
+	stateStarting    = unitState{"activating", "start"}
+	stateAutoRestart = unitState{"activating", "auto-restart"}
+	stateStopping    = unitState{"deactivating", "stop"}
+	stateFailed      = unitState{"failed", "failed"}
+	stateDead        = unitState{"inactive", "dead"}
+)
+
+// isActive reports whether a service counts as active for is-active and
+// status, which includes reloading
+func (s unitState) isActive() bool {
+	return s.Active == "active" || s.Active == "reloading"
+}
+
+// getUnitState returns the state of a service. Services that do not exist
+// are inactive (dead), as in systemd.
+func getUnitState(serviceName string) (unitState, error) {
+	if err := checkServiceExists(serviceName); err != nil {
+		return stateDead, err
+	}
+
+	if reader, ok := manager.(backend.StateReader); ok {
+		if state, err := reader.ServiceState(serviceName); err == nil {
+			return mapServiceState(state), nil
+		}
+	}
+
+	status, err := manager.Status(serviceName)
+	if err != nil {
+		return stateDead, err
+	}
+	return mapStatus(status), nil
+}
+
+// mapServiceState maps the state OpenRC keeps for a service to systemd's
+// states:
+//
+//	started, lock held by a reload         reloading (reload)
+//	started, supervised daemon restarting  activating (auto-restart)
+//	started, no daemon (a oneshot)         active (exited)
+//	started                                active (running)
+//	starting, before a daemon is started   activating (start-pre)
+//	starting                               activating (start)
+//	inactive (started, waiting on e.g. a network link)
+//	                                       activating (start)
+//	stopping                               deactivating (stop)
+//	crashed                                failed (failed)
+//	stopped, last start or stop failed     failed (failed)
+//	stopped, scheduled to start with another service
+//	                                       activating (start-pre)
+//	stopped, wasinactive (between the stop and start of a restart)
+//	                                       activating (start-pre)
+//	stopped                                inactive (dead)
+func mapServiceState(state *backend.ServiceState) unitState {
+	switch state.State {
+	case "started":
+		switch {
+		case state.Busy:
+			return stateReloading
+		case state.Options["child_pid"] != "" && state.ChildPID == 0 && state.SupervisorPID != 0:
+			return stateAutoRestart
+		case len(state.Daemons) == 0 && state.ChildPID == 0:
+			return stateExited
+		}
+		return stateRunning
+	case "starting":
+		if len(state.Daemons) == 0 {
+			return stateStartPre
+		}
+		return stateStarting
+	case "inactive":
+		return stateStarting
+	case "stopping":
+		return stateStopping
+	case "crashed":
+		return stateFailed
+	}
+
+	switch {
+	case state.Failed:
+		return stateFailed
+	case state.Scheduled, state.WasInactive:
+		return stateStartPre
+	}
+	return stateDead
+}
+
+// mapStatus maps the state reported by rc-service status, or by a backend
+// without OpenRC's state directory, to systemd's states. The state word is
+// preferred; the exit code is used when there is none.
+func mapStatus(status backend.Status) unitState {
+	switch status.State {
+	case "started":
+		return stateRunning
+	case "starting", "inactive":
+		return stateStarting
+	case "stopping":
+		return stateStopping
+	case "crashed", "failed":
+		return stateFailed
+	case "stopped":
+		return stateDead
+	}
+
+	switch status.ExitCode {
+	case 0:
+		return stateRunning
+	case 3:
+		return stateDead
+	case 4:
+		return stateStopping
+	case 8, 16:
+		return stateStarting
+	}
+	return stateFailed
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"testing"
+
+	"systemctl-alpine/pkg/backend"
+)
+
+func TestMapServiceState(t *testing.T) {
+	daemon := []backend.Daemon{{Exec: "/usr/sbin/nginx"}}
+	supervised := map[string]string{"child_pid": "1234"}
+
+	tests := []struct {
+		name  string
+		state backend.ServiceState
+		want  unitState
+	}{
+		{"started", backend.ServiceState{State: "started", Daemons: daemon}, stateRunning},
+		{"started and busy", backend.ServiceState{State: "started", Daemons: daemon, Busy: true}, stateReloading},
+		{"started, busy without daemons", backend.ServiceState{State: "started", Busy: true}, stateReloading},
+		{"supervised child running", backend.ServiceState{State: "started", Daemons: daemon, Options: supervised, SupervisorPID: 10, ChildPID: 1234}, stateRunning},
+		{"supervised child dead, supervisor alive", backend.ServiceState{State: "started", Daemons: daemon, Options: supervised, SupervisorPID: 10}, stateAutoRestart},
+		{"supervised child alive without recorded daemons", backend.ServiceState{State: "started", ChildPID: 1234}, stateRunning},
+		{"started without daemons", backend.ServiceState{State: "started"}, stateExited},
+		{"starting without daemons", backend.ServiceState{State: "starting"}, stateStartPre},
+		{"starting with daemons", backend.ServiceState{State: "starting", Daemons: daemon}, stateStarting},
+		{"inactive", backend.ServiceState{State: "inactive"}, stateStarting},
+		{"stopping", backend.ServiceState{State: "stopping", Daemons: daemon}, stateStopping},
+		{"crashed", backend.ServiceState{State: "crashed", Daemons: daemon}, stateFailed},
+		{"stopped", backend.ServiceState{State: "stopped"}, stateDead},
+		{"stopped and failed", backend.ServiceState{State: "stopped", Failed: true}, stateFailed},
+		{"stopped and scheduled", backend.ServiceState{State: "stopped", Scheduled: true}, stateStartPre},
+		{"stopped, was inactive", backend.ServiceState{State: "stopped", WasInactive: true}, stateStartPre},
+		{"failed wins over scheduled", backend.ServiceState{State: "stopped", Failed: true, Scheduled: true}, stateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapServiceState(&tt.state); got != tt.want {
+				t.Errorf("mapServiceState = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status backend.Status
+		want   unitState
+	}{
+		{"started", backend.Status{State: "started"}, stateRunning},
+		{"starting", backend.Status{State: "starting", ExitCode: 8}, stateStarting},
+		{"inactive", backend.Status{State: "inactive", ExitCode: 16}, stateStarting},
+		{"stopping", backend.Status{State: "stopping", ExitCode: 4}, stateStopping},
+		{"crashed", backend.Status{State: "crashed", ExitCode: 32}, stateFailed},
+		{"failed", backend.Status{State: "failed", ExitCode: 1}, stateFailed},
+		{"stopped", backend.Status{State: "stopped", ExitCode: 3}, stateDead},
+		{"state word wins over exit code", backend.Status{State: "stopped", ExitCode: 0}, stateDead},
+
+		// Without a state word, the exit code of rc-service status is used
+		{"exit code 0", backend.Status{ExitCode: 0}, stateRunning},
+		{"exit code 3", backend.Status{ExitCode: 3}, stateDead},
+		{"exit code 4", backend.Status{ExitCode: 4}, stateStopping},
+		{"exit code 8", backend.Status{ExitCode: 8}, stateStarting},
+		{"exit code 16", backend.Status{ExitCode: 16}, stateStarting},
+		{"exit code 32", backend.Status{ExitCode: 32}, stateFailed},
+		{"exit code 1", backend.Status{ExitCode: 1}, stateFailed},
+		{"unknown state word", backend.Status{State: "weird", ExitCode: 3}, stateDead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapStatus(tt.status); got != tt.want {
+				t.Errorf("mapStatus = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitStateIsActive(t *testing.T) {
+	for state, want := range map[unitState]bool{
+		stateRunning:     true,
+		stateExited:      true,
+		stateReloading:   true,
+		stateStartPre:    false,
+		stateStarting:    false,
+		stateAutoRestart: false,
+		stateStopping:    false,
+		stateFailed:      false,
+		stateDead:        false,
+	} {
+		if got := state.isActive(); got != want {
+			t.Errorf("%v.isActive() = %v, want %v", state, got, want)
+		}
+	}
+}
@@ -1,31 +1,314 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"systemctl-alpine/pkg/backend"
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/proc"
 	"systemctl-alpine/pkg/util"
 
 	"github.com/spf13/cobra"
 )
 
+var statusLinesFlag int
+
 var statusCmd = &cobra.Command{
-	Use:   "status [service]",
-	Short: "Check the status of a service",
-	Long: `Check the status of a service using OpenRC's rc-service command.
+	Use:   "status [service...]",
+	Short: "Show runtime status of one or more services",
+	Long: `Show terse runtime status information about one or more services in the
+same layout as systemd, followed by the most recent log lines.
+
+The status is assembled from the OpenRC service state, the service's pidfile,
+/proc, the service's control group and its log files.
+
+Use -o json for machine-readable output keyed by systemd property names.
+
+Service names may be shell-style glob patterns, which are matched against known
+services.
+
+Returns exit code 0 if all services are running, 3 if a service is not
+running and 4 if a service does not exist.
 
 Example:
-  ` + cliName + ` status nginx`,
-	Args: cobra.ExactArgs(1),
+  ` + cliName + ` status nginx
+  ` + cliName + ` status nginx redis
+  ` + cliName + ` status 'php-fpm@*'
+  ` + cliName + ` status -n 50 nginx`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		serviceName := util.NormalizeServiceName(args[0])
+		// Like systemd, the exit status is that of the first unit that is not running
+		exitCode := ExitSuccess
+		var objects []jsonObject
 
-		if err := checkServiceExists(serviceName); err != nil {
+		services, err := expandServiceArgs(args)
+		if err != nil {
 			return err
 		}
 
-		return executeServiceCommand(serviceName, "status")
+		// The services are queried in parallel and shown in order
+		statuses := parallelMap(services, func(serviceName string) *unitStatus {
+			status, _ := getUnitStatus(serviceName, statusLinesFlag)
+			return status
+		})
+
+		w, done := startPager()
+		defer done()
+
+		// Blocks are separated by blank lines; missing units print none
+		printed := false
+		for i, serviceName := range services {
+			status := statuses[i]
+			if status == nil {
+				fmt.Fprintf(os.Stderr, "Unit %s.service could not be found.\n", serviceName)
+				if exitCode == ExitSuccess {
+					exitCode = ExitProgramUnknown
+				}
+				continue
+			}
+
+			if isJSONOutput() {
+				objects = append(objects, status.jsonObject())
+			} else {
+				if printed {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprint(w, formatUnitStatus(status))
+				printed = true
+			}
+
+			if status.ActiveState != "active" && status.ActiveState != "reloading" && exitCode == ExitSuccess {
+				exitCode = ExitProgramNotRunning
+			}
+		}
+
+		if isJSONOutput() {
+			if err := renderJSON(os.Stdout, objects); err != nil {
+				return err
+			}
+		}
+
+		return exitSilently(exitCode)
 	},
 	SilenceUsage: true,
 }
 
+// unitStatus is the runtime picture of a service shown by `status`
+type unitStatus struct {
+	Name          string
+	Description   string
+	LoadState     string
+	FragmentPath  string
+	UnitFileState string
+	ActiveState   string
+	SubState      string
+	Since         time.Time
+	MainPID       *proc.Process
+	Tasks         uint64
+	Memory        uint64
+	CGroup        string
+	Processes     []*proc.Process
+	LogLines      []string
+}
+
+// getUnitStatus gathers the runtime status of a service. It fails if the
+// service has neither an OpenRC script nor a systemd unit file.
+func getUnitStatus(serviceName string, logLines int) (*unitStatus, error) {
+	status := &unitStatus{
+		Name:        serviceName,
+		LoadState:   "loaded",
+		ActiveState: "inactive",
+		SubState:    "dead",
+	}
+
+	systemdFiles, _ := getSystemdServiceFiles()
+	unitPath, hasUnit := systemdFiles[serviceName]
+
+	if err := checkServiceExists(serviceName); err != nil {
+		if !hasUnit {
+			return nil, err
+		}
+		// Unit file exists but has not been converted yet
+		status.FragmentPath = paths.Unresolve(unitPath)
+		status.UnitFileState = "disabled"
+		status.Description = getServiceDescription(serviceName)
+		return status, nil
+	}
+
+	// Paths are shown as seen from inside the root
+	status.FragmentPath = paths.Unresolve(paths.InitScript(serviceName))
+	if hasUnit {
+		status.FragmentPath = paths.Unresolve(unitPath)
+	}
+
+	config, _ := parseOpenRCScript(serviceName)
+	status.Description = getServiceDescription(serviceName)
+
+	status.UnitFileState = "disabled"
+	if enabled, _ := isServiceEnabled(serviceName); enabled {
+		status.UnitFileState = "enabled"
+	}
+
+	state, _ := getUnitState(serviceName)
+	status.ActiveState = state.Active
+	status.SubState = state.Sub
+
+	// OpenRC marks started services with a symlink created at start time
+	if state, err := backend.ReadServiceState(serviceName); err == nil {
+		status.Since = state.Since
+	}
+
+	if pid := getMainPID(serviceName, config); pid > 0 {
+		if p, err := proc.Get(pid); err == nil {
+			status.MainPID = p
+			if status.Since.IsZero() {
+				status.Since = p.StartTime
+			}
+		}
+	}
+
+	mainPID := 0
+	if status.MainPID != nil {
+		mainPID = status.MainPID.PID
+	}
+	status.CGroup, status.Processes = getServiceProcesses(serviceName, mainPID)
+
+	// Prefer the cgroup accounting and fall back to summing the processes
+	var haveTasks, haveMemory bool
+	if status.CGroup != "" {
+		status.Tasks, haveTasks = proc.CGroupTasks(status.CGroup)
+		status.Memory, haveMemory = proc.CGroupMemory(status.CGroup)
+	}
+	for _, p := range status.Processes {
+		if !haveTasks {
+			status.Tasks += uint64(p.Threads)
+		}
+		if !haveMemory {
+			status.Memory += p.RSS
+		}
+	}
+
+	status.LogLines = getServiceLogLines(serviceName, config, logLines)
+
+	return status, nil
+}
+
+// jsonObject returns the status as a JSON object keyed by systemd property names
+func (status *unitStatus) jsonObject() jsonObject {
+	var object jsonObject
+	object.set("Id", status.Name+".service")
+	object.set("Description", status.Description)
+	object.set("LoadState", status.LoadState)
+	object.set("FragmentPath", status.FragmentPath)
+	object.set("UnitFileState", status.UnitFileState)
+	object.set("ActiveState", status.ActiveState)
+	object.set("SubState", status.SubState)
+
+	since := ""
+	if !status.Since.IsZero() {
+		since = util.FormatTimestamp(status.Since)
+	}
+	object.set("ActiveEnterTimestamp", since)
+
+	mainPID := 0
+	if status.MainPID != nil {
+		mainPID = status.MainPID.PID
+	}
+	object.set("MainPID", mainPID)
+	object.set("TasksCurrent", status.Tasks)
+	object.set("MemoryCurrent", status.Memory)
+	object.set("ControlGroup", status.CGroup)
+
+	processes := make([]jsonObject, 0, len(status.Processes))
+	for _, p := range status.Processes {
+		var process jsonObject
+		process.set("pid", p.PID)
+		process.set("command", p.Cmdline)
+		processes = append(processes, process)
+	}
+	object.set("Processes", processes)
+
+	logLines := status.LogLines
+	if logLines == nil {
+		logLines = []string{}
+	}
+	object.set("LogLines", logLines)
+
+	return object
+}
+
+// formatUnitStatus renders a unit status in systemd's status layout
+func formatUnitStatus(status *unitStatus) string {
+	var output strings.Builder
+
+	bullet := "○"
+	switch status.ActiveState {
+	case "active", "reloading", "activating", "deactivating":
+		bullet = "●"
+	case "failed":
+		bullet = "×"
+	}
+
+	header := bullet + " " + status.Name + ".service"
+	if status.Description != "" {
+		header += " - " + status.Description
+	}
+	output.WriteString(header + "\n")
+
+	writeField := func(label, value string) {
+		fmt.Fprintf(&output, "%12s %s\n", label+":", value)
+	}
+
+	writeField("Loaded", fmt.Sprintf("%s (%s; %s)", status.LoadState, status.FragmentPath, status.UnitFileState))
+
+	active := fmt.Sprintf("%s (%s)", status.ActiveState, status.SubState)
+	if !status.Since.IsZero() && status.ActiveState != "inactive" {
+		active += " since " + util.FormatTimestamp(status.Since) + "; " + util.FormatTimestampRelative(status.Since)
+	}
+	writeField("Active", active)
+
+	if status.MainPID != nil {
+		writeField("Main PID", formatPID(status.MainPID))
+	}
+
+	if len(status.Processes) > 0 {
+		writeField("Tasks", strconv.FormatUint(status.Tasks, 10))
+		writeField("Memory", util.FormatBytes(status.Memory))
+
+		cgroup := status.CGroup
+		if cgroup == "" {
+			cgroup = "(no control group)"
+		}
+		writeField("CGroup", cgroup)
+
+		for i, p := range status.Processes {
+			branch := "├─"
+			if i == len(status.Processes)-1 {
+				branch = "└─"
+			}
+			cmdline := p.Cmdline
+			if cmdline == "" {
+				cmdline = "[" + p.Comm + "]"
+			}
+			fmt.Fprintf(&output, "%12s %s%d %s\n", "", branch, p.PID, cmdline)
+		}
+	}
+
+	if len(status.LogLines) > 0 {
+		output.WriteString("\n")
+		for _, line := range status.LogLines {
+			output.WriteString(line + "\n")
+		}
+	}
+
+	return output.String()
+}
+
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().IntVarP(&statusLinesFlag, "lines", "n", 10, "Number of log lines to show")
 }
@@ -1,27 +1,22 @@
 package cmd
 
 import (
-	"systemctl-alpine/pkg/util"
-
 	"github.com/spf13/cobra"
 )
 
 var stopCmd = &cobra.Command{
-	Use:   "stop [service]",
-	Short: "Stop a service",
-	Long: `Stop a service using OpenRC's rc-service command.
+	Use:   "stop [service...]",
+	Short: "Stop one or more services",
+	Long: `Stop one or more services using OpenRC's rc-service command, before the
+services they depend on.
 
 Example:
-  ` + cliName + ` stop nginx`,
-	Args: cobra.ExactArgs(1),
+  ` + cliName + ` stop nginx
+  ` + cliName + ` stop nginx postgresql
+  ` + cliName + ` stop 'php-fpm@*'`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		serviceName := util.NormalizeServiceName(args[0])
-
-		if err := checkServiceExists(serviceName); err != nil {
-			return err
-		}
-
-		return executeServiceCommand(serviceName, "stop")
+		return runServiceCommand(args, "stop")
 	},
 	SilenceUsage: true,
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"systemctl-alpine/pkg/backend"
+)
+
+// getSystemState returns the state of the system in systemd's terms and the
+// services that have failed:
+//
+//	offline       OpenRC has not been booted
+//	initializing  OpenRC is starting the sysinit or boot runlevel
+//	starting      OpenRC is starting another runlevel
+//	stopping      OpenRC is stopping a runlevel or shutting down
+//	maintenance   the system is in the single runlevel
+//	degraded      services have failed
+//	running       otherwise
+func getSystemState() (string, []string) {
+	runlevel, err := backend.ReadRunlevelState()
+	if err != nil {
+		return "offline", nil
+	}
+
+	failed := getFailedServices()
+
+	switch {
+	case runlevel.Stopping, runlevel.Runlevel == "shutdown", runlevel.Runlevel == "reboot":
+		return "stopping", failed
+	case runlevel.Starting && (runlevel.Runlevel == "sysinit" || runlevel.Runlevel == "boot"):
+		return "initializing", failed
+	case runlevel.Starting:
+		return "starting", failed
+	case runlevel.Runlevel == "single":
+		return "maintenance", failed
+	case len(failed) > 0:
+		return "degraded", failed
+	}
+	return "running", nil
+}
+
+// getFailedServices returns the OpenRC services whose ActiveState is failed
+func getFailedServices() []string {
+	services, _ := getAllServices()
+	states := parallelMap(services, func(serviceName string) unitState {
+		state, _ := getUnitState(serviceName)
+		return state
+	})
+
+	var failed []string
+	for i, serviceName := range services {
+		if states[i].Active == "failed" {
+			failed = append(failed, serviceName)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"systemctl-alpine/pkg/converter"
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/util"
+
+	"github.com/spf13/cobra"
+)
+
+var templatePrintFlag bool
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage the templates used to generate OpenRC scripts",
+	Long: `Manage the templates used to generate OpenRC scripts.
+
+OpenRC scripts are generated from a built-in template. It can be overridden
+by files in /etc/systemctl-alpine/templates (see template_dir in the
+configuration file):
+
+  openrc.tpl       used for every service
+  <name>@.tpl      used for every instance of a template unit
+  <name>.tpl       used for a single service
+
+Templates use Go's text/template syntax. An override can include the
+built-in template with {{template "default" .}} and add to it.`,
+	SilenceUsage: true,
+}
+
+var templateCheckCmd = &cobra.Command{
+	Use:   "check [service|file...]",
+	Short: "Check that templates render valid OpenRC scripts",
+	Long: `Check that templates parse and render an OpenRC script.
+
+Without arguments every template in the template directory is checked, or the
+built-in template if there are none. A file argument checks that template; a
+service argument checks the template selected for the service, rendered with
+the service's unit file when it has one.
+
+Templates without a unit file are rendered with sample data that sets every
+field, so that references to unknown fields are reported.
+
+Example:
+  ` + cliName + ` template check
+  ` + cliName + ` template check ./openrc.tpl
+  ` + cliName + ` template check --print nginx`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			var err error
+			if args, err = listTemplateFiles(); err != nil {
+				return err
+			}
+		}
+
+		failed := 0
+		for _, arg := range args {
+			source, script, err := checkTemplate(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", source, err)
+				failed++
+				continue
+			}
+
+			if templatePrintFlag {
+				fmt.Print(script)
+				continue
+			}
+			fmt.Printf("%s: OK\n", source)
+		}
+
+		if failed > 0 {
+			return exitWith(ExitFailure, fmt.Errorf("%d of %d templates failed the check", failed, len(args)))
+		}
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+// listTemplateFiles returns the override templates in the template
+// directory, or the built-in template when there are none
+func listTemplateFiles() ([]string, error) {
+	dir := paths.Resolve(converter.Defaults.TemplateDir)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tpl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	if len(files) == 0 {
+		return []string{converter.BuiltinTemplate}, nil
+	}
+	return files, nil
+}
+
+// isTemplateFile reports whether an argument names a template file rather
+// than a service
+func isTemplateFile(arg string) bool {
+	return strings.HasSuffix(arg, ".tpl") || strings.ContainsRune(arg, '/')
+}
+
+// checkTemplate parses and renders the template named by an argument and
+// returns where the template came from and the rendered script
+func checkTemplate(arg string) (string, string, error) {
+	data := converter.SampleData()
+
+	var tmpl *template.Template
+	var source string
+	var err error
+
+	switch {
+	case arg == converter.BuiltinTemplate:
+		source = arg
+		tmpl, err = converter.ParseBuiltinTemplate()
+	case isTemplateFile(arg):
+		source = arg
+		tmpl, err = converter.ParseTemplateFile(arg)
+	default:
+		serviceName := util.NormalizeServiceName(arg)
+		tmpl, source, err = converter.LoadTemplate(serviceName)
+		if err != nil {
+			break
+		}
+
+		data.Name = serviceName
+		if unitFile, found := findUnitFile(serviceName); found {
+			_, instanceName := splitUnitName(serviceName)
+			unit, parseErr := parser.ParseServiceFile(unitFile, instanceName)
+			if parseErr != nil {
+				return source, "", parseErr
+			}
+			if data, err = converter.NewTemplateData(unit, serviceName, instanceName); err != nil {
+				return source, "", fmt.Errorf("%s: %w", unitFile, err)
+			}
+		}
+	}
+	if err != nil {
+		return source, "", err
+	}
+
+	script, err := converter.CheckTemplate(tmpl, data)
+	return source, script, err
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateCheckCmd)
+	templateCheckCmd.Flags().BoolVarP(&templatePrintFlag, "print", "p", false, "Print the rendered script instead of OK")
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// ANSI sequences used by systemd for highlighting
+const (
+	ansiHighlightRed   = "\x1b[0;1;31m"
+	ansiHighlightGreen = "\x1b[0;1;32m"
+	ansiNormal         = "\x1b[0m"
+)
+
+// defaultTerminalWidth is used when the width of the terminal is unknown
+const defaultTerminalWidth = 80
+
+var (
+	noLegendFlag bool
+	noPagerFlag  bool
+	plainFlag    bool
+	fullFlag     bool
+)
+
+// isTerminal reports whether f is connected to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the number of columns of the terminal on stdout,
+// honoring $COLUMNS like systemd does
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	var size struct {
+		Rows, Cols, X, Y uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 || size.Cols == 0 {
+		return defaultTerminalWidth
+	}
+
+	return int(size.Cols)
+}
+
+// colorsEnabled reports whether output should be colorized.
+// $SYSTEMD_COLORS forces colors on or off; $NO_COLOR and --plain turn them off.
+func colorsEnabled() bool {
+	if value := os.Getenv("SYSTEMD_COLORS"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		return err != nil || enabled
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || plainFlag {
+		return false
+	}
+	return isTerminal(os.Stdout) && os.Getenv("TERM") != "dumb"
+}
+
+// colorize wraps text in an ANSI color when colors are enabled
+func colorize(color, text string) string {
+	if color == "" || !colorsEnabled() {
+		return text
+	}
+	return color + text + ansiNormal
+}
+
+// stateColor returns the highlight color systemd uses for a unit state
+func stateColor(state string) string {
+	switch state {
+	case "active", "running", "enabled":
+		return ansiHighlightGreen
+	case "failed", "not-found", "masked":
+		return ansiHighlightRed
+	}
+	return ""
+}
+
+// ellipsize shortens text to width runes, marking the cut with an ellipsis
+func ellipsize(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 0 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// startPager starts the pager for long output when stdout is a terminal,
+// using $SYSTEMD_PAGER, $PAGER, less or more in that order. It returns the
+// writer to print to and a function that waits for the pager to exit.
+func startPager() (io.Writer, func()) {
+	stdout := func() (io.Writer, func()) { return os.Stdout, func() {} }
+
+	if noPagerFlag || isJSONOutput() || !isTerminal(os.Stdout) {
+		return stdout()
+	}
+
+	pager, ok := os.LookupEnv("SYSTEMD_PAGER")
+	if !ok {
+		pager, ok = os.LookupEnv("PAGER")
+	}
+	if ok && (strings.TrimSpace(pager) == "" || pager == "cat") {
+		return stdout()
+	}
+
+	var candidates []string
+	if ok {
+		candidates = append(candidates, pager)
+	}
+	candidates = append(candidates, "less", "more")
+
+	for _, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		path, err := exec.LookPath(fields[0])
+		if err != nil {
+			continue
+		}
+
+		cmd := exec.Command(path, fields[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		// Same defaults as systemd: quit if one screen, raw colors, no init
+		cmd.Env = append(os.Environ(), "LESS="+lookupEnvDefault("SYSTEMD_LESS", "FRSXMK"))
+
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			continue
+		}
+		if err := cmd.Start(); err != nil {
+			continue
+		}
+
+		return stdin, func() {
+			stdin.Close()
+			cmd.Wait()
+		}
+	}
+
+	return stdout()
+}
+
+// lookupEnvDefault returns the value of an environment variable or a default
+func lookupEnvDefault(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
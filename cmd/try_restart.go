@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var tryRestartCmd = &cobra.Command{
+	Use:     "try-restart [service...]",
+	Aliases: []string{"condrestart"},
+	Short:   "Restart one or more services if they are running",
+	Long: `Restart one or more services if they are running.
+Services that are not running are left alone, as with systemd.
+
+Example:
+  ` + cliName + ` try-restart nginx
+  ` + cliName + ` condrestart nginx redis`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachService(args, "try-restart", func(serviceName string) error {
+			return conditionalRestart(serviceName, true, false)
+		})
+	},
+	SilenceUsage: true,
+}
+
+// conditionalRestart implements the try-restart and reload-or-restart
+// family of commands. With onlyIfRunning, a service that is not running is
+// left alone; with preferReload, a running service whose script supports
+// reload is reloaded instead of restarted. The service must exist.
+func conditionalRestart(serviceName string, onlyIfRunning, preferReload bool) error {
+	state, err := getUnitState(serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to get state of %s: %w", serviceName, err)
+	}
+	running := state.isActive()
+
+	switch {
+	case !running && onlyIfRunning:
+		return nil
+	case !running:
+		return executeServiceCommand(serviceName, "start")
+	case preferReload && scriptSupportsReload(serviceName):
+		return executeServiceCommand(serviceName, "reload")
+	}
+	return executeServiceCommand(serviceName, "restart")
+}
+
+func init() {
+	rootCmd.AddCommand(tryRestartCmd)
+}
@@ -1,171 +1,181 @@
 package cmd
 
 import (
-	"bufio"
-	"bytes"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
+	"systemctl-alpine/pkg/backend"
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/util"
+
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
 // checkServiceExists verifies that the service exists in /etc/init.d/
 func checkServiceExists(serviceName string) error {
-	path := filepath.Join("/etc/init.d", serviceName)
+	path := paths.InitScript(serviceName)
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("service %s does not exist", serviceName)
+		return &UnitNotFoundError{Name: serviceName}
 	}
 	return nil
 }
 
-// executeServiceCommand runs an rc-service command on the specified service
+// executeServiceCommand runs an action (start, stop, restart, reload) on
+// the specified service through the service manager
 func executeServiceCommand(serviceName, command string) error {
-	titleCaser := cases.Title(language.English)
-	fmt.Printf("%s service %s...\n", titleCaser.String(command)+"ing", serviceName)
-
-	cmd := exec.Command("rc-service", serviceName, command)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		// For status command, don't treat non-zero exit as an error
-		if command == "status" {
-			fmt.Printf("Service %s might be stopped or has issues\n", serviceName)
-			return nil
-		}
-		return fmt.Errorf("failed to %s service: %w", command, err)
+	// "stop" doubles its final consonant: stopping, stopped
+	stem := command
+	if command == "stop" {
+		stem = "stopp"
 	}
 
-	// Only print success message for non-status commands
-	if command != "status" {
-		fmt.Printf("Service %s %sed\n", serviceName, command)
+	titleCaser := cases.Title(language.English)
+	fmt.Printf("%s service %s...\n", titleCaser.String(stem)+"ing", serviceName)
+
+	var err error
+	switch command {
+	case "start":
+		err = manager.Start(serviceName)
+	case "stop":
+		err = manager.Stop(serviceName)
+	case "restart":
+		err = manager.Restart(serviceName)
+	case "reload":
+		err = manager.Reload(serviceName)
+	default:
+		return exitWith(ExitNotImplemented, fmt.Errorf("unsupported command %q", command))
+	}
+	if err != nil {
+		return err
 	}
 
+	fmt.Printf("Service %s %sed\n", serviceName, stem)
+
 	return nil
 }
 
-// isServiceEnabled checks if a service is enabled in the default runlevel
-func isServiceEnabled(serviceName string) (bool, error) {
-	// Run rc-update show default to get the list of enabled services
-	rcUpdateCmd := exec.Command("rc-update", "show", "default")
-	var out bytes.Buffer
-	rcUpdateCmd.Stdout = &out
+// runServiceCommand runs an action on every service named by args, which
+// may be shell-style glob patterns. Services are started, restarted and
+// reloaded after the services they depend on, and stopped before them.
+// Like systemd, a failure is reported and the remaining services are still
+// processed; the exit status is that of the first failure.
+func runServiceCommand(args []string, command string) error {
+	return forEachService(args, command, func(serviceName string) error {
+		return executeServiceCommand(serviceName, command)
+	})
+}
 
-	if err := rcUpdateCmd.Run(); err != nil {
-		return false, fmt.Errorf("failed to get enabled services: %w", err)
+// forEachService calls action for every service named by args, in
+// dependency order (reversed for stop). Failures are reported as
+// "Failed to <command> <service>.service: ..." without stopping the
+// others, and the exit status is that of the first failure.
+func forEachService(args []string, command string, action func(serviceName string) error) error {
+	services, err := expandServiceArgs(args)
+	if err != nil {
+		return err
 	}
 
-	// Check if the service is in the output
-	scanner := bufio.NewScanner(&out)
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = strings.TrimSpace(line)
-		if line == "" {
+	services = orderServices(services)
+	if command == "stop" {
+		slices.Reverse(services)
+	}
+
+	exitCode := ExitSuccess
+	for _, serviceName := range services {
+		err := checkServiceExists(serviceName)
+		if err == nil {
+			err = action(serviceName)
+		}
+		if err == nil {
 			continue
 		}
-		fields := strings.Fields(line)
 
-		// The output format is: "servicename | default"
-		if len(fields) >= 3 && fields[0] == serviceName && fields[1] == "|" && fields[2] == "default" {
-			return true, nil
+		var notFound *UnitNotFoundError
+		if errors.As(err, &notFound) {
+			fmt.Fprintf(os.Stderr, "Failed to %s %s.service: Unit %s.service not found.\n", command, serviceName, serviceName)
+		} else {
+			fmt.Fprintf(os.Stderr, "Failed to %s %s.service: %v\n", command, serviceName, err)
+		}
+		if exitCode == ExitSuccess {
+			exitCode = ExitCode(err)
 		}
 	}
 
-	return false, nil
+	return exitSilently(exitCode)
 }
 
-// getEnabledServices returns a map of service names that are enabled in OpenRC
-func getEnabledServices() (map[string]bool, error) {
-	enabledServices := make(map[string]bool)
-
-	// Run rc-update show default to get the list of enabled services
-	cmd := exec.Command("rc-update", "show", "default")
-	output, err := cmd.Output()
+// isServiceEnabled checks if a service is enabled in the default runlevel
+func isServiceEnabled(serviceName string) (bool, error) {
+	enabledServices, err := getEnabledServices()
 	if err != nil {
-		return nil, err
-	}
-
-	// Parse the output
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) >= 3 && fields[1] == "|" && fields[2] == "default" {
-			serviceName := fields[0]
-			enabledServices[serviceName] = true
-		}
+		return false, err
 	}
 
-	return enabledServices, nil
+	return enabledServices[serviceName], nil
 }
 
-// getServiceState returns the active state and exit code of a service
-// Maps rc-service exit codes to systemd states:
-// - 0 (running) → "active"
-// - 1 (stopped) → "inactive"
-// - 3 (crashed) → "failed"
-// Returns (state, exitCode, error)
-func getServiceState(serviceName string) (string, int, error) {
-	// Check if service exists
-	if err := checkServiceExists(serviceName); err != nil {
-		return "unknown", 1, err
-	}
-
-	// Run rc-service status and capture exit code
-	cmd := exec.Command("rc-service", serviceName, "status")
-	output, err := cmd.CombinedOutput()
-	exitCode := 0
+// listEnabledServices returns a map of service names that are enabled in OpenRC
+func listEnabledServices() (map[string]bool, error) {
+	services, err := manager.ListRunlevel(backend.DefaultRunlevel)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		}
+		return nil, fmt.Errorf("failed to get enabled services: %w", err)
 	}
 
-	var state string
-
-	if len(output) > 0 {
-		_, state, _ = strings.Cut(string(output), ": ")
-		state = strings.TrimSpace(state)
+	enabledServices := make(map[string]bool)
+	for _, serviceName := range services {
+		enabledServices[serviceName] = true
 	}
 
-	// Map exit codes to systemd states
-	switch exitCode {
-	case 0:
-		state = "active"
-	case 1, 2:
-		state = "failed"
-	case 3:
-		state = "inactive"
-	default:
-		if state == "" {
-			state = "unknown"
-		}
-	}
+	return enabledServices, nil
+}
 
-	return state, exitCode, nil
+// openrcScriptKeys lists the variables extracted from OpenRC init scripts
+var openrcScriptKeys = []string{
+	"description",
+	"command",
+	"command_args",
+	"command_user",
+	"directory",
+	"pidfile",
+	"command_background",
+	"supervisor",
+	"output_log",
+	"error_log",
 }
 
 // parseOpenRCScript extracts configuration from an OpenRC init script
+// and its configuration file in /etc/conf.d, which OpenRC reads first.
 // Returns a map of configuration keys to values
 func parseOpenRCScript(serviceName string) (map[string]string, error) {
 	config := make(map[string]string)
 
-	path := filepath.Join("/etc/init.d", serviceName)
+	if content, err := os.ReadFile(paths.ConfFile(serviceName)); err == nil {
+		parseScriptAssignments(string(content), config)
+	}
+
+	path := paths.InitScript(serviceName)
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return config, err
 	}
 
-	lines := strings.Split(string(content), "\n")
+	parseScriptAssignments(string(content), config)
+
+	return config, nil
+}
+
+// parseScriptAssignments records the assignments of openrcScriptKeys in
+// shell code; later assignments win
+func parseScriptAssignments(content string, config map[string]string) {
+	lines := strings.Split(content, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 
@@ -174,148 +184,88 @@ func parseOpenRCScript(serviceName string) (map[string]string, error) {
 			continue
 		}
 
-		// Extract description
-		if strings.Contains(line, "description=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				desc := strings.Trim(parts[1], "\"'")
-				config["description"] = desc
+		for _, key := range openrcScriptKeys {
+			if !strings.Contains(line, key+"=") {
+				continue
 			}
-		}
-
-		// Extract command
-		if strings.Contains(line, "command=") {
 			parts := strings.SplitN(line, "=", 2)
 			if len(parts) == 2 {
-				cmd := strings.Trim(parts[1], "\"'")
-				config["command"] = cmd
+				config[key] = strings.Trim(parts[1], "\"'")
 			}
 		}
+	}
+}
 
-		// Extract command_user
-		if strings.Contains(line, "command_user=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				user := strings.Trim(parts[1], "\"'")
-				config["command_user"] = user
-			}
+// expandScriptVars expands the shell variables commonly used in OpenRC
+// script assignments, such as pidfile="/run/$name/$name.pid"
+func expandScriptVars(value, serviceName string, config map[string]string) string {
+	return os.Expand(value, func(key string) string {
+		// Support the ${VAR:-default} form used for name
+		key, fallback, _ := strings.Cut(key, ":-")
+		switch key {
+		case "RC_SVCNAME", "SVCNAME", "name":
+			return serviceName
 		}
-
-		// Extract directory
-		if strings.Contains(line, "directory=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				dir := strings.Trim(parts[1], "\"'")
-				config["directory"] = dir
-			}
+		if v, ok := config[key]; ok {
+			return v
 		}
-
-		// Extract pidfile
-		if strings.Contains(line, "pidfile=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				pidfile := strings.Trim(parts[1], "\"'")
-				config["pidfile"] = pidfile
-			}
-		}
-
-		// Extract command_background
-		if strings.Contains(line, "command_background=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				bg := strings.Trim(parts[1], "\"'")
-				config["command_background"] = bg
-			}
-		}
-	}
-
-	return config, nil
+		return fallback
+	})
 }
 
-// getServiceProperties gathers all properties for a service
-func getServiceProperties(serviceName string) (map[string]string, error) {
-	properties := make(map[string]string)
-
-	// Basic properties
-	properties["Id"] = serviceName + ".service"
-
-	// Check if service exists and is loaded
-	loadState := "not-found"
-	if err := checkServiceExists(serviceName); err == nil {
-		loadState = "loaded"
-	}
-	properties["LoadState"] = loadState
-
-	// Parse OpenRC script for static properties
-	openrcConfig, _ := parseOpenRCScript(serviceName)
-
-	// Get description
-	if desc, ok := openrcConfig["description"]; ok {
-		properties["Description"] = desc
-	} else {
-		properties["Description"] = ""
-	}
-
-	// Get active state
-	state, _, _ := getServiceState(serviceName)
-	properties["ActiveState"] = state
-	properties["SubState"] = getSubState(state)
-
-	// Get unit file state (enabled/disabled)
-	isEnabled, _ := isServiceEnabled(serviceName)
-	unitFileState := "disabled"
-	if isEnabled {
-		unitFileState = "enabled"
-	}
-	properties["UnitFileState"] = unitFileState
+// reloadCommandsPattern matches the assignments that make OpenRC accept a
+// command, e.g. extra_started_commands="${extra_started_commands} reload"
+var reloadCommandsPattern = regexp.MustCompile(`^(?:export\s+)?(?:extra_started_commands|extra_commands|opts)=(.*)$`)
 
-	// Get ExecStart from command
-	if cmd, ok := openrcConfig["command"]; ok {
-		properties["ExecStart"] = cmd
-	}
+// reloadFunctionPattern matches the definition of a reload function
+var reloadFunctionPattern = regexp.MustCompile(`(?m)^\s*(?:function\s+)?reload\s*\(\s*\)`)
 
-	// Determine Type based on command_background
-	serviceType := "simple"
-	if bg, ok := openrcConfig["command_background"]; ok && bg == "true" {
-		serviceType = "simple"
-	} else {
-		serviceType = "forking"
+// scriptSupportsReload reports whether the OpenRC script of a service can be
+// reloaded: it must define a reload function and declare it as a command,
+// since openrc-run refuses to run functions that are not declared
+func scriptSupportsReload(serviceName string) bool {
+	content, err := os.ReadFile(paths.InitScript(serviceName))
+	if err != nil || !reloadFunctionPattern.Match(content) {
+		return false
 	}
-	properties["Type"] = serviceType
 
-	// Get User
-	if user, ok := openrcConfig["command_user"]; ok {
-		properties["User"] = user
-	}
-
-	// Get WorkingDirectory
-	if dir, ok := openrcConfig["directory"]; ok {
-		properties["WorkingDirectory"] = dir
-	}
-
-	// Get PIDFile
-	if pidfile, ok := openrcConfig["pidfile"]; ok {
-		properties["PIDFile"] = pidfile
+	for _, line := range strings.Split(string(content), "\n") {
+		match := reloadCommandsPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		for _, command := range strings.Fields(strings.Trim(match[1], "\"'")) {
+			if command == "reload" {
+				return true
+			}
+		}
 	}
 
-	return properties, nil
+	return false
 }
 
-// formatShowOutput formats properties for display with optional filtering
-func formatShowOutput(properties map[string]string, requestedProps []string, valueOnly bool) string {
-	var output strings.Builder
-
+// filterShowProperties returns the sorted property names to display
+// and the properties they refer to, with optional filtering. Empty
+// properties are left out unless showAll is set or they are requested.
+func filterShowProperties(properties map[string]string, requestedProps []string, showAll bool) ([]string, map[string]string) {
 	// If specific properties requested, filter them
 	var propsToShow map[string]string
 	if len(requestedProps) > 0 {
 		propsToShow = make(map[string]string)
 		for _, prop := range requestedProps {
-			if val, ok := properties[prop]; ok {
-				propsToShow[prop] = val
+			// Requested properties are printed even when unknown, so that
+			// every requested value gets a line with --value
+			if prop = strings.TrimSpace(prop); prop != "" {
+				propsToShow[prop] = properties[prop]
 			}
 		}
 	} else {
-		propsToShow = properties
+		propsToShow = make(map[string]string)
+		for prop, val := range properties {
+			if val != "" || showAll {
+				propsToShow[prop] = val
+			}
+		}
 	}
 
 	// Sort keys for consistent output
@@ -325,6 +275,27 @@ func formatShowOutput(properties map[string]string, requestedProps []string, val
 	}
 	sort.Strings(keys)
 
+	return keys, propsToShow
+}
+
+// showOutputObject builds the JSON object printed by show -o json
+func showOutputObject(properties map[string]string, requestedProps []string, showAll bool) jsonObject {
+	keys, propsToShow := filterShowProperties(properties, requestedProps, showAll)
+
+	var object jsonObject
+	for _, key := range keys {
+		object.set(key, propsToShow[key])
+	}
+
+	return object
+}
+
+// formatShowOutput formats properties for display with optional filtering
+func formatShowOutput(properties map[string]string, requestedProps []string, valueOnly, showAll bool) string {
+	var output strings.Builder
+
+	keys, propsToShow := filterShowProperties(properties, requestedProps, showAll)
+
 	// Format output
 	for _, key := range keys {
 		if valueOnly {
@@ -337,17 +308,17 @@ func formatShowOutput(properties map[string]string, requestedProps []string, val
 	return output.String()
 }
 
-// getSystemdServiceFiles returns a map of all systemd service files
-// Maps service name to file path
-func getSystemdServiceFiles() (map[string]string, error) {
+// scanSystemdServiceFiles indexes the systemd service files in the unit
+// directories by service name
+func scanSystemdServiceFiles() map[string]string {
 	serviceFiles := make(map[string]string)
 
-	locations := []string{
-		"/etc/systemd/system",
-		"/lib/systemd/system",
-	}
+	// Walk locations from lowest to highest precedence so that
+	// /etc/systemd/system overrides the vendor units
+	locations := paths.UnitDirs()
+	for i := len(locations) - 1; i >= 0; i-- {
+		location := locations[i]
 
-	for _, location := range locations {
 		// Check if directory exists
 		if _, err := os.Stat(location); os.IsNotExist(err) {
 			continue
@@ -368,7 +339,7 @@ func getSystemdServiceFiles() (map[string]string, error) {
 		}
 	}
 
-	return serviceFiles, nil
+	return serviceFiles
 }
 
 // getAllServices returns a list of all available services (from systemd and OpenRC)
@@ -382,7 +353,7 @@ func getAllServices() ([]string, error) {
 	}
 
 	// Add OpenRC services
-	openrcDir := "/etc/init.d"
+	openrcDir := paths.Resolve(paths.InitDir())
 	if files, err := os.ReadDir(openrcDir); err == nil {
 		for _, file := range files {
 			// Skip directories and hidden files
@@ -402,8 +373,9 @@ func getAllServices() ([]string, error) {
 	return services, nil
 }
 
-// getServiceDescription returns the description of a service from OpenRC or systemd
-func getServiceDescription(serviceName string) string {
+// readServiceDescription reads the description of a service from its OpenRC
+// script or its systemd unit file
+func readServiceDescription(serviceName string) string {
 	// Try to get from OpenRC script
 	config, err := parseOpenRCScript(serviceName)
 	if err == nil {
@@ -431,17 +403,178 @@ func getServiceDescription(serviceName string) string {
 	return ""
 }
 
-// getSubState derives a sub-state from the active state
-// Maps systemd substates based on main state
-func getSubState(activeState string) string {
-	switch activeState {
-	case "active":
-		return "running"
-	case "inactive":
-		return "dead"
-	case "failed":
-		return "failed"
-	default:
-		return "unknown"
+// isServicePattern reports whether a service argument is a glob pattern
+func isServicePattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// expandServiceArgs normalizes service arguments and expands shell-style
+// glob patterns (e.g. 'php-fpm@*') against the known services. Patterns
+// that match nothing are dropped, as systemd does.
+func expandServiceArgs(args []string) ([]string, error) {
+	var services []string
+	var known []string
+	seen := make(map[string]bool)
+
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			services = append(services, name)
+		}
+	}
+
+	for _, arg := range args {
+		name := util.NormalizeServiceName(arg)
+		if !isServicePattern(name) {
+			add(name)
+			continue
+		}
+
+		if known == nil {
+			known, _ = getAllServices()
+		}
+		for _, service := range known {
+			matched, err := filepath.Match(name, service)
+			if err != nil {
+				return nil, exitWith(ExitInvalidArgument, fmt.Errorf("invalid pattern %q: %w", arg, err))
+			}
+			if matched {
+				add(service)
+			}
+		}
+	}
+
+	return services, nil
+}
+
+// getServiceRunlevels returns the runlevels under /etc/runlevels that contain the service
+func getServiceRunlevels(serviceName string) []string {
+	return backend.RunlevelsOf(serviceName)
+}
+
+// isServiceMasked reports whether the service has been masked by linking its
+// unit file or init script to /dev/null
+func isServiceMasked(serviceName string) bool {
+	candidates := []string{paths.InitScript(serviceName)}
+	for _, location := range paths.UnitDirs() {
+		candidates = append(candidates, filepath.Join(location, serviceName+".service"))
 	}
+
+	return slices.ContainsFunc(candidates, isMaskLink)
+}
+
+// getUnitFileState returns the enablement state of a service using the
+// systemd vocabulary (enabled, enabled-runtime, alias, indirect, generated,
+// static, masked, disabled). Returns an error if the service does not exist.
+func getUnitFileState(serviceName string) (string, error) {
+	if isServiceMasked(serviceName) {
+		return "masked", nil
+	}
+
+	systemdFiles, _ := getSystemdServiceFiles()
+	unitPath, hasUnit := systemdFiles[serviceName]
+	scriptPath := paths.InitScript(serviceName)
+	hasScript := checkServiceExists(serviceName) == nil
+
+	if !hasUnit && !hasScript {
+		return "", &UnitNotFoundError{Name: serviceName}
+	}
+
+	if hasScript {
+		if enabled, _ := isServiceEnabled(serviceName); enabled || len(getServiceRunlevels(serviceName)) > 0 {
+			return "enabled", nil
+		}
+		// Services started by hotplug are enabled for this boot only
+		if state, err := backend.ReadServiceState(serviceName); err == nil && state.Hotplugged {
+			return "enabled-runtime", nil
+		}
+	}
+
+	// A unit file or init script that links to another service is an alias
+	for _, path := range []string{unitPath, scriptPath} {
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "alias", nil
+		}
+	}
+
+	// Templates are enabled through their instances
+	if strings.HasSuffix(serviceName, "@") {
+		instances, _ := filepath.Glob(filepath.Join(paths.Resolve(paths.RunlevelsDir), "*", serviceName+"*"))
+		if len(instances) > 0 {
+			return "indirect", nil
+		}
+	}
+
+	if hasScript && !hasUnit {
+		source := getConvertedSourcePath(serviceName)
+		switch {
+		case source == "":
+			// An OpenRC-only service in no runlevel can be enabled with
+			// rc-update, so it is disabled rather than static
+			return "disabled", nil
+		case !fileExists(source):
+			// A converted script whose unit file has since been removed
+			return "generated", nil
+		}
+		// Template instances are converted from the template's unit file
+		unitPath = source
+	}
+
+	if config, err := parser.ParseServiceFile(unitPath, ""); err == nil && !config.HasInstallSection() {
+		return "static", nil
+	}
+
+	return "disabled", nil
+}
+
+// isUnitFileStateEnabled reports whether a unit file state counts as enabled
+// for the exit status of is-enabled
+func isUnitFileStateEnabled(state string) bool {
+	switch state {
+	case "enabled", "enabled-runtime", "alias", "indirect", "static":
+		return true
+	}
+	return false
+}
+
+// getConvertedSourcePath returns the location of the systemd unit an OpenRC
+// script was converted from
+func getConvertedSourcePath(serviceName string) string {
+	content, err := os.ReadFile(paths.InitScript(serviceName))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if source, ok := strings.CutPrefix(line, "# Converted from systemd service: "); ok {
+			return paths.Resolve(strings.TrimSpace(source))
+		}
+	}
+
+	return ""
+}
+
+// splitUnitName returns the unit file name of a service and, for instances
+// of template units (foo@bar), the instance name. The unit file of an
+// instance is the template, foo@.service.
+func splitUnitName(serviceName string) (string, string) {
+	if prefix, instance, ok := strings.Cut(serviceName, "@"); ok {
+		return prefix + "@.service", util.NormalizeServiceName(instance)
+	}
+	return util.NormalizeServiceName(serviceName) + ".service", ""
+}
+
+// findUnitFile returns the systemd unit file of a service from the
+// highest-precedence unit directory that has one
+func findUnitFile(serviceName string) (string, bool) {
+	unitName, _ := splitUnitName(serviceName)
+
+	for _, location := range paths.UnitDirs() {
+		path := filepath.Join(location, unitName)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+
+	return "", false
 }
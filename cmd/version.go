@@ -9,14 +9,29 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
-	Long:  `Show the version of systemctl-alpine.`,
+	Long: `Show the version of systemctl-alpine, also printed by --version.
+
+The first line follows systemd's "systemd <N> (<version>)" so that tools that
+check the systemd version can parse it. N is the emulated systemd version, set
+with systemd_version in the configuration file (default 252).
+
+Example:
+  ` + cliName + ` --version`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Printf("%s (alpine translator) version %s\n", cliName, Version)
+		printVersion()
 		return nil
 	},
 	SilenceUsage: true,
 }
 
+// printVersion prints the emulated systemd version and the version of
+// systemctl-alpine
+func printVersion() {
+	fmt.Printf("systemd %d (systemctl-alpine %s)\n", cfg.SystemdVersion, Version)
+	fmt.Println("Services are managed by OpenRC")
+}
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
 }
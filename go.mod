@@ -4,10 +4,8 @@ go 1.24.1
 
 require (
 	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
 	golang.org/x/text v0.23.0
 )
 
-require (
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
-)
+require github.com/inconshreveable/mousetrap v1.1.0 // indirect
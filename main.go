@@ -14,6 +14,6 @@ var version string
 func main() {
 	cmd.Version = strings.TrimSpace(version)
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,36 @@
+package backend
+
+// DefaultRunlevel is the runlevel services are enabled in
+const DefaultRunlevel = "default"
+
+// Status is the state of a service as reported by the service manager
+type Status struct {
+	// State is the init system's word for the state, e.g. "started",
+	// "stopped" or "crashed"
+	State string
+	// ExitCode is the exit status of the status query
+	ExitCode int
+}
+
+// ServiceManager controls services through the init system. The OpenRC
+// implementation reads OpenRC's state and runlevel directories and runs
+// rc-service and rc-update; the fake implementation
+// keeps everything in memory so commands can be tested without OpenRC.
+type ServiceManager interface {
+	// Start starts a service
+	Start(name string) error
+	// Stop stops a service
+	Stop(name string) error
+	// Restart stops and starts a service
+	Restart(name string) error
+	// Reload asks a service to reload its configuration
+	Reload(name string) error
+	// Status queries the state of a service
+	Status(name string) (Status, error)
+	// Enable adds a service to a runlevel
+	Enable(name, runlevel string) error
+	// Disable removes a service from a runlevel
+	Disable(name, runlevel string) error
+	// ListRunlevel returns the services in a runlevel
+	ListRunlevel(runlevel string) ([]string, error)
+}
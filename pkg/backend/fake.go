@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Fake is an in-memory service manager for tests. Services must be added
+// with AddService before they can be controlled; every call is recorded.
+type Fake struct {
+	mu        sync.Mutex
+	states    map[string]string
+	runlevels map[string]map[string]bool
+	failures  map[string]error
+
+	// calls records each call as "method name [runlevel]"
+	calls []string
+}
+
+// NewFake returns an empty fake service manager
+func NewFake() *Fake {
+	return &Fake{
+		states:    make(map[string]string),
+		runlevels: make(map[string]map[string]bool),
+		failures:  make(map[string]error),
+	}
+}
+
+// AddService registers a service in the given state ("started", "stopped", "crashed", ...)
+func (f *Fake) AddService(name, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[name] = state
+}
+
+// FailOn makes the given method fail with err for a service
+func (f *Fake) FailOn(method, name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method+" "+name] = err
+}
+
+// Calls returns the calls made so far, each as "method name [runlevel]"
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// record logs a call and returns the failure configured for it, if any
+func (f *Fake) record(method, name string, extra ...string) error {
+	call := method + " " + name
+	for _, e := range extra {
+		call += " " + e
+	}
+	f.calls = append(f.calls, call)
+
+	if err, ok := f.failures[method+" "+name]; ok {
+		return err
+	}
+	if _, ok := f.states[name]; !ok {
+		return fmt.Errorf("service %s does not exist", name)
+	}
+	return nil
+}
+
+// setState records a call and moves the service to a new state
+func (f *Fake) setState(method, name, state string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(method, name); err != nil {
+		return err
+	}
+	f.states[name] = state
+	return nil
+}
+
+// Start starts a service
+func (f *Fake) Start(name string) error {
+	return f.setState("start", name, "started")
+}
+
+// Stop stops a service
+func (f *Fake) Stop(name string) error {
+	return f.setState("stop", name, "stopped")
+}
+
+// Restart stops and starts a service
+func (f *Fake) Restart(name string) error {
+	return f.setState("restart", name, "started")
+}
+
+// Reload asks a service to reload its configuration
+func (f *Fake) Reload(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("reload", name); err != nil {
+		return err
+	}
+	if f.states[name] != "started" {
+		return fmt.Errorf("service %s is not started", name)
+	}
+	return nil
+}
+
+// Status returns the state of a service with the exit codes of rc-service
+func (f *Fake) Status(name string) (Status, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("status", name); err != nil {
+		return Status{}, err
+	}
+
+	state := f.states[name]
+	status := Status{State: state}
+	switch state {
+	case "started":
+		status.ExitCode = 0
+	case "crashed":
+		status.ExitCode = 32
+	default:
+		status.ExitCode = 3
+	}
+	return status, nil
+}
+
+// Enable adds a service to a runlevel
+func (f *Fake) Enable(name, runlevel string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("enable", name, runlevel); err != nil {
+		return err
+	}
+	if f.runlevels[runlevel] == nil {
+		f.runlevels[runlevel] = make(map[string]bool)
+	}
+	f.runlevels[runlevel][name] = true
+	return nil
+}
+
+// Disable removes a service from a runlevel
+func (f *Fake) Disable(name, runlevel string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("disable", name, runlevel); err != nil {
+		return err
+	}
+	delete(f.runlevels[runlevel], name)
+	return nil
+}
+
+// ListRunlevel returns the services in a runlevel, sorted by name
+func (f *Fake) ListRunlevel(runlevel string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var services []string
+	for name := range f.runlevels[runlevel] {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services, nil
+}
@@ -0,0 +1,136 @@
+package backend
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestFakeStateTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		initial  string
+		call     func(f *Fake) error
+		wantErr  bool
+		wantCode int
+		want     string
+	}{
+		{"start", "stopped", func(f *Fake) error { return f.Start("svc") }, false, 0, "started"},
+		{"stop", "started", func(f *Fake) error { return f.Stop("svc") }, false, 3, "stopped"},
+		{"restart stopped", "stopped", func(f *Fake) error { return f.Restart("svc") }, false, 0, "started"},
+		{"reload started", "started", func(f *Fake) error { return f.Reload("svc") }, false, 0, "started"},
+		{"reload stopped", "stopped", func(f *Fake) error { return f.Reload("svc") }, true, 3, "stopped"},
+		{"crashed", "crashed", func(f *Fake) error { return nil }, false, 32, "crashed"},
+		{"unknown service", "stopped", func(f *Fake) error { return f.Start("other") }, true, 3, "stopped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake()
+			f.AddService("svc", tt.initial)
+
+			if err := tt.call(f); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			status, err := f.Status("svc")
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			if status.State != tt.want || status.ExitCode != tt.wantCode {
+				t.Errorf("Status = %+v, want state %q and exit code %d", status, tt.want, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestFakeFailOn(t *testing.T) {
+	f := NewFake()
+	f.AddService("svc", "stopped")
+	failure := errors.New("boom")
+	f.FailOn("start", "svc", failure)
+
+	if err := f.Start("svc"); !errors.Is(err, failure) {
+		t.Fatalf("Start error = %v, want %v", err, failure)
+	}
+	if status, _ := f.Status("svc"); status.State != "stopped" {
+		t.Errorf("state after failed start = %q, want stopped", status.State)
+	}
+	if err := f.Stop("svc"); err != nil {
+		t.Errorf("Stop error = %v, want nil", err)
+	}
+}
+
+func TestFakeRunlevels(t *testing.T) {
+	f := NewFake()
+	f.AddService("b", "stopped")
+	f.AddService("a", "stopped")
+
+	for _, name := range []string{"b", "a"} {
+		if err := f.Enable(name, "default"); err != nil {
+			t.Fatalf("Enable %s: %v", name, err)
+		}
+	}
+	if err := f.Enable("missing", "default"); err == nil {
+		t.Error("Enable of an unknown service succeeded")
+	}
+
+	services, _ := f.ListRunlevel("default")
+	if want := []string{"a", "b"}; !slices.Equal(services, want) {
+		t.Errorf("ListRunlevel = %v, want %v", services, want)
+	}
+
+	if err := f.Disable("b", "default"); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	services, _ = f.ListRunlevel("default")
+	if want := []string{"a"}; !slices.Equal(services, want) {
+		t.Errorf("ListRunlevel after Disable = %v, want %v", services, want)
+	}
+	if services, _ := f.ListRunlevel("boot"); len(services) != 0 {
+		t.Errorf("ListRunlevel of an empty runlevel = %v", services)
+	}
+}
+
+func TestFakeCalls(t *testing.T) {
+	f := NewFake()
+	f.AddService("svc", "stopped")
+	f.Start("svc")
+	f.Enable("svc", "default")
+	f.Status("svc")
+
+	want := []string{"start svc", "enable svc default", "status svc"}
+	calls := f.Calls()
+	if !slices.Equal(calls, want) {
+		t.Fatalf("Calls = %v, want %v", calls, want)
+	}
+
+	// The returned slice is a copy
+	calls[0] = "changed"
+	if f.Calls()[0] != "start svc" {
+		t.Error("Calls returned the internal slice")
+	}
+}
+
+func TestFakeConcurrentCalls(t *testing.T) {
+	f := NewFake()
+	f.AddService("svc", "stopped")
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			f.Start("svc")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = f.Calls()
+		}()
+	}
+	wg.Wait()
+
+	if n := len(f.Calls()); n != 20 {
+		t.Errorf("recorded %d calls, want 20", n)
+	}
+}
@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"systemctl-alpine/pkg/paths"
+)
+
+// ErrOffline is returned for actions that need a running OpenRC
+var ErrOffline = errors.New("OpenRC is not running (offline mode)")
+
+// Offline manages runlevels by creating and removing the symlinks in
+// /etc/runlevels directly, as rc-update does. It is used with --root and
+// when OpenRC has not been booted, e.g. while building a container image.
+// Services cannot be started or stopped and are always reported stopped.
+type Offline struct{}
+
+// NewOffline returns an offline service manager
+func NewOffline() *Offline {
+	return &Offline{}
+}
+
+// Booted reports whether OpenRC has been booted on the system
+func Booted() bool {
+	_, err := os.Stat(paths.OpenRCState("softlevel"))
+	return err == nil
+}
+
+// Start fails with ErrOffline
+func (o *Offline) Start(name string) error {
+	return ErrOffline
+}
+
+// Stop fails with ErrOffline
+func (o *Offline) Stop(name string) error {
+	return ErrOffline
+}
+
+// Restart fails with ErrOffline
+func (o *Offline) Restart(name string) error {
+	return ErrOffline
+}
+
+// Reload fails with ErrOffline
+func (o *Offline) Reload(name string) error {
+	return ErrOffline
+}
+
+// Status reports every service as stopped
+func (o *Offline) Status(name string) (Status, error) {
+	return Status{State: "stopped", ExitCode: 3}, nil
+}
+
+// Enable links the service's init script into the runlevel directory
+func (o *Offline) Enable(name, runlevel string) error {
+	if _, err := os.Stat(paths.InitScript(name)); err != nil {
+		return fmt.Errorf("service %s does not exist", name)
+	}
+
+	dir := paths.Runlevel(runlevel)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create runlevel %s: %w", runlevel, err)
+	}
+
+	// The link target is the script's location inside the root, like rc-update
+	link := filepath.Join(dir, name)
+	target := filepath.Join(paths.InitDir(), name)
+	if current, err := os.Readlink(link); err == nil && current == target {
+		return nil
+	}
+	os.Remove(link)
+
+	return os.Symlink(target, link)
+}
+
+// Disable removes the service's link from the runlevel directory
+func (o *Offline) Disable(name, runlevel string) error {
+	err := os.Remove(filepath.Join(paths.Runlevel(runlevel), name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ListRunlevel returns the services linked into a runlevel directory
+func (o *Offline) ListRunlevel(runlevel string) ([]string, error) {
+	services, err := ReadRunlevel(runlevel)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return services, err
+}
@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// OpenRC manages services with the rc-service and rc-update commands. State
+// and runlevel queries read /run/openrc and /etc/runlevels directly.
+type OpenRC struct {
+	// Stdout and Stderr receive the output of rc-service actions
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewOpenRC returns an OpenRC service manager printing to the process output
+func NewOpenRC() *OpenRC {
+	return &OpenRC{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Start starts a service
+func (o *OpenRC) Start(name string) error {
+	return o.run(name, "start")
+}
+
+// Stop stops a service
+func (o *OpenRC) Stop(name string) error {
+	return o.run(name, "stop")
+}
+
+// Restart stops and starts a service
+func (o *OpenRC) Restart(name string) error {
+	return o.run(name, "restart")
+}
+
+// Reload asks a service to reload its configuration
+func (o *OpenRC) Reload(name string) error {
+	return o.run(name, "reload")
+}
+
+// run executes an rc-service action with output going to the terminal
+func (o *OpenRC) run(name, action string) error {
+	cmd := exec.Command("rc-service", name, action)
+	cmd.Stdout = o.Stdout
+	cmd.Stderr = o.Stderr
+	return cmd.Run()
+}
+
+// Status returns the state of a service from OpenRC's state directory and
+// falls back to rc-service status if it cannot be read. A non-zero exit
+// status is not an error; it is part of the result.
+func (o *OpenRC) Status(name string) (Status, error) {
+	if state, err := ReadServiceState(name); err == nil {
+		return state.Status(), nil
+	}
+
+	cmd := exec.Command("rc-service", name, "status")
+	output, err := cmd.CombinedOutput()
+
+	status := Status{}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return status, err
+		}
+		status.ExitCode = exitErr.ExitCode()
+	}
+
+	// The output format is: " * status: started"
+	if _, state, ok := strings.Cut(string(output), ": "); ok {
+		status.State = strings.TrimSpace(state)
+	}
+
+	return status, nil
+}
+
+// ServiceState reads the state of a service from OpenRC's state directory
+func (o *OpenRC) ServiceState(name string) (*ServiceState, error) {
+	return ReadServiceState(name)
+}
+
+// Enable adds a service to a runlevel with rc-update add
+func (o *OpenRC) Enable(name, runlevel string) error {
+	return exec.Command("rc-update", "add", name, runlevel).Run()
+}
+
+// Disable removes a service from a runlevel with rc-update del
+func (o *OpenRC) Disable(name, runlevel string) error {
+	return exec.Command("rc-update", "del", name, runlevel).Run()
+}
+
+// ListRunlevel returns the services in a runlevel from its directory in
+// /etc/runlevels and falls back to parsing rc-update show
+func (o *OpenRC) ListRunlevel(runlevel string) ([]string, error) {
+	if services, err := ReadRunlevel(runlevel); err == nil {
+		return services, nil
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command("rc-update", "show", runlevel)
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list runlevel %s: %w", runlevel, err)
+	}
+
+	var services []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		// The output format is: "servicename | default"
+		if len(fields) >= 3 && fields[1] == "|" && fields[2] == runlevel {
+			services = append(services, fields[0])
+		}
+	}
+
+	return services, scanner.Err()
+}
@@ -0,0 +1,284 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/proc"
+)
+
+// OpenRC keeps the state of every service as entries named after the
+// service in directories of /run/openrc, one per state
+var stateDirs = []string{"started", "starting", "stopping", "inactive", "wasinactive", "failed", "hotplugged"}
+
+// Daemon is a process started for a service by start-stop-daemon or
+// supervise-daemon, as recorded in /run/openrc/daemons/<service>/
+type Daemon struct {
+	Exec    string
+	Argv0   string
+	Name    string
+	Pidfile string
+}
+
+// ServiceState is the state of a service read from OpenRC's state directory
+type ServiceState struct {
+	Name string
+	// State is the word rc-service status prints: started, stopped,
+	// starting, stopping, inactive or crashed
+	State string
+	// Since is when the service entered its current state; it is zero for
+	// stopped services
+	Since time.Time
+	// Failed is set if the last start or stop of the service failed
+	Failed bool
+	// Hotplugged is set if the service was started by a hotplug event
+	Hotplugged bool
+	// WasInactive is set while a service that was inactive is restarted
+	WasInactive bool
+	// Scheduled is set if the service is to be started when another
+	// service starts
+	Scheduled bool
+	// Busy is set while an openrc-run process holds the service's lock
+	Busy bool
+	// Daemons are the processes started for the service
+	Daemons []Daemon
+	// Options are the values stored by the service, e.g. child_pid
+	Options map[string]string
+	// SupervisorPID and ChildPID are the pids of supervise-daemon and of
+	// the daemon it supervises, or 0
+	SupervisorPID int
+	ChildPID      int
+}
+
+// ErrNoState is returned when OpenRC's state directory cannot be read
+var ErrNoState = errors.New("OpenRC state directory is not available")
+
+// StateReader is implemented by service managers that can report the state
+// of a service in detail
+type StateReader interface {
+	// ServiceState returns the state of a service
+	ServiceState(name string) (*ServiceState, error)
+}
+
+// ReadServiceState reads the state of a service from /run/openrc
+func ReadServiceState(name string) (*ServiceState, error) {
+	if !Booted() {
+		return nil, ErrNoState
+	}
+
+	state := &ServiceState{
+		Name:    name,
+		State:   "stopped",
+		Options: readOptions(name),
+	}
+
+	in := make(map[string]time.Time)
+	for _, dir := range stateDirs {
+		info, err := os.Lstat(paths.OpenRCState(dir, name))
+		switch {
+		case err == nil:
+			in[dir] = info.ModTime()
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("%w: %v", ErrNoState, err)
+		}
+	}
+
+	// The order follows rc-service status
+	for _, dir := range []string{"stopping", "starting", "inactive", "started"} {
+		if since, ok := in[dir]; ok {
+			state.State, state.Since = dir, since
+			break
+		}
+	}
+	_, state.Failed = in["failed"]
+	_, state.Hotplugged = in["hotplugged"]
+	_, state.WasInactive = in["wasinactive"]
+	// Services are scheduled in /run/openrc/scheduled/<service>/, named
+	// after the service whose start will start them
+	scheduled, _ := filepath.Glob(paths.OpenRCState("scheduled", "*", name))
+	state.Scheduled = len(scheduled) > 0
+	state.Busy = isLocked(paths.OpenRCState("exclusive", name))
+	state.Daemons = readDaemons(name)
+
+	if pid, err := strconv.Atoi(state.Options["child_pid"]); err == nil && proc.Alive(pid) {
+		state.ChildPID = pid
+	}
+	// supervise-daemon records its own pidfile next to the child pid
+	if pidfile := state.Options["pidfile"]; pidfile != "" && state.Options["child_pid"] != "" {
+		if pid, err := proc.ReadPidFile(pidfile); err == nil && proc.Alive(pid) {
+			state.SupervisorPID = pid
+		}
+	}
+
+	if state.State == "started" && daemonsCrashed(state) {
+		state.State = "crashed"
+	}
+
+	return state, nil
+}
+
+// Status returns the state as rc-service status reports it
+func (s *ServiceState) Status() Status {
+	switch s.State {
+	case "started":
+		return Status{State: s.State, ExitCode: 0}
+	case "stopping":
+		return Status{State: s.State, ExitCode: 4}
+	case "starting":
+		return Status{State: s.State, ExitCode: 8}
+	case "inactive":
+		return Status{State: s.State, ExitCode: 16}
+	case "crashed":
+		return Status{State: s.State, ExitCode: 32}
+	}
+	if s.Failed {
+		return Status{State: "failed", ExitCode: 1}
+	}
+	return Status{State: s.State, ExitCode: 3}
+}
+
+// readOptions reads the values a service stored in /run/openrc/options/<service>/
+func readOptions(name string) map[string]string {
+	options := make(map[string]string)
+
+	dir := paths.OpenRCState("options", name)
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if value, err := os.ReadFile(filepath.Join(dir, entry.Name())); err == nil {
+			options[entry.Name()] = strings.TrimSpace(string(value))
+		}
+	}
+
+	return options
+}
+
+// readDaemons reads the daemons recorded in /run/openrc/daemons/<service>/,
+// one file of key=value lines per daemon
+func readDaemons(name string) []Daemon {
+	var daemons []Daemon
+
+	files, _ := filepath.Glob(paths.OpenRCState("daemons", name, "*"))
+	slices.Sort(files)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		var daemon Daemon
+		for _, line := range strings.Split(string(content), "\n") {
+			key, value, _ := strings.Cut(line, "=")
+			switch key {
+			case "exec":
+				daemon.Exec = value
+			case "argv_0":
+				daemon.Argv0 = value
+			case "name":
+				daemon.Name = value
+			case "pidfile":
+				daemon.Pidfile = value
+			}
+		}
+		daemons = append(daemons, daemon)
+	}
+
+	return daemons
+}
+
+// daemonsCrashed reports whether a daemon of a started service has died,
+// like rc_service_daemons_crashed. Only daemons with a pidfile can be
+// checked.
+func daemonsCrashed(state *ServiceState) bool {
+	for _, daemon := range state.Daemons {
+		if daemon.Pidfile == "" {
+			continue
+		}
+		pid, err := proc.ReadPidFile(daemon.Pidfile)
+		if err != nil || !proc.Alive(pid) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocked reports whether a lock file is locked by another process
+func isLocked(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err != nil {
+		return errors.Is(err, syscall.EWOULDBLOCK)
+	}
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return false
+}
+
+// RunlevelState is the state of OpenRC's runlevels
+type RunlevelState struct {
+	// Runlevel is the current runlevel
+	Runlevel string
+	// Starting and Stopping are set while OpenRC changes runlevels, e.g.
+	// during boot and shutdown
+	Starting bool
+	Stopping bool
+}
+
+// ReadRunlevelState reads the current runlevel from /run/openrc/softlevel
+// and whether OpenRC is changing runlevels, like rc_runlevel_starting and
+// rc_runlevel_stopping
+func ReadRunlevelState() (*RunlevelState, error) {
+	data, err := os.ReadFile(paths.OpenRCState("softlevel"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoState, err)
+	}
+
+	state := &RunlevelState{Runlevel: strings.TrimSpace(string(data))}
+	if _, err := os.Stat(paths.OpenRCState("rc.starting")); err == nil {
+		state.Starting = true
+	}
+	if _, err := os.Stat(paths.OpenRCState("rc.stopping")); err == nil {
+		state.Stopping = true
+	}
+
+	return state, nil
+}
+
+// ReadRunlevel returns the services linked into a runlevel directory
+func ReadRunlevel(runlevel string) ([]string, error) {
+	entries, err := os.ReadDir(paths.Runlevel(runlevel))
+	if err != nil {
+		return nil, err
+	}
+
+	var services []string
+	for _, entry := range entries {
+		services = append(services, entry.Name())
+	}
+	slices.Sort(services)
+
+	return services, nil
+}
+
+// RunlevelsOf returns the runlevels under /etc/runlevels that contain a service
+func RunlevelsOf(name string) []string {
+	var runlevels []string
+
+	entries, _ := filepath.Glob(filepath.Join(paths.Resolve(paths.RunlevelsDir), "*", name))
+	for _, entry := range entries {
+		runlevels = append(runlevels, filepath.Base(filepath.Dir(entry)))
+	}
+	slices.Sort(runlevels)
+
+	return runlevels
+}
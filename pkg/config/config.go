@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultPath is the location of the configuration file
+const DefaultPath = "/etc/systemctl-alpine/config.toml"
+
+// EnvPath names the environment variable that overrides DefaultPath
+const EnvPath = "SYSTEMCTL_ALPINE_CONFIG"
+
+// DefaultSystemdVersion is the systemd version emulated by default
+const DefaultSystemdVersion = 252
+
+// Config is the systemctl-alpine configuration
+type Config struct {
+	// UnitPaths are the systemd unit file locations, in order of precedence
+	UnitPaths []string
+	// Runlevels maps systemd targets to OpenRC runlevels
+	Runlevels map[string]string
+	// DefaultRunlevel is used for targets without a mapping
+	DefaultRunlevel string
+	// Converter holds the defaults for generated OpenRC scripts
+	Converter Converter
+	// SystemdVersion is the systemd version reported by --version and
+	// show, for tools that check it
+	SystemdVersion int64
+	// Path is the file the configuration was loaded from, if any
+	Path string
+}
+
+// Converter holds the defaults for generated OpenRC scripts
+type Converter struct {
+	// Depend lists the entries of the generated depend() function
+	Depend []string
+	// Supervisor is the OpenRC supervisor (empty for start-stop-daemon)
+	Supervisor string
+	// InitDir is where OpenRC scripts are written
+	InitDir string
+	// ConfDir is where OpenRC configuration files are written
+	ConfDir string
+	// TemplateDir holds OpenRC script template overrides
+	TemplateDir string
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		UnitPaths: []string{
+			"/etc/systemd/system",
+			"/run/systemd/system",
+			"/usr/local/lib/systemd/system",
+			"/usr/lib/systemd/system",
+			"/lib/systemd/system",
+		},
+		Runlevels: map[string]string{
+			"sysinit.target":    "sysinit",
+			"basic.target":      "boot",
+			"multi-user.target": "default",
+			"graphical.target":  "default",
+			"default.target":    "default",
+			"shutdown.target":   "shutdown",
+		},
+		DefaultRunlevel: "default",
+		SystemdVersion:  DefaultSystemdVersion,
+		Converter: Converter{
+			Depend:      []string{"need net", "after firewall"},
+			InitDir:     "/etc/init.d",
+			ConfDir:     "/etc/conf.d",
+			TemplateDir: "/etc/systemctl-alpine/templates",
+		},
+	}
+}
+
+// Load reads the configuration file at path on top of the defaults.
+// A missing file is not an error; the defaults are returned.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	doc, err := parseTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := cfg.apply(doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+
+	return cfg, nil
+}
+
+// apply overrides the configuration with the values of a parsed document
+func (c *Config) apply(doc document) error {
+	for table, keys := range doc {
+		for key, value := range keys {
+			var err error
+			switch table + "." + key {
+			case ".unit_paths":
+				c.UnitPaths, err = stringList(key, value)
+			case ".default_runlevel":
+				c.DefaultRunlevel, err = stringValue(key, value)
+			case ".systemd_version":
+				c.SystemdVersion, err = positiveInt(key, value)
+			case "converter.depend":
+				c.Converter.Depend, err = stringList(key, value)
+			case "converter.supervisor":
+				c.Converter.Supervisor, err = stringValue(key, value)
+			case "converter.init_dir":
+				c.Converter.InitDir, err = stringValue(key, value)
+			case "converter.conf_dir":
+				c.Converter.ConfDir, err = stringValue(key, value)
+			case "converter.template_dir":
+				c.Converter.TemplateDir, err = stringValue(key, value)
+			default:
+				if table != "runlevels" {
+					return fmt.Errorf("unknown setting %q", strings.TrimPrefix(table+"."+key, "."))
+				}
+				c.Runlevels[key], err = stringValue(key, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// OpenRC's s6 and runit supervisors need service directories, which
+	// the converter does not write
+	switch c.Converter.Supervisor {
+	case "", "start-stop-daemon", "supervise-daemon":
+	case "s6", "runit":
+		return fmt.Errorf("supervisor %q is not supported by the converter; use supervise-daemon or start-stop-daemon", c.Converter.Supervisor)
+	default:
+		return fmt.Errorf("unknown supervisor %q", c.Converter.Supervisor)
+	}
+
+	return nil
+}
+
+// Runlevel returns the OpenRC runlevel for the targets of a WantedBy= or
+// RequiredBy= setting; the first mapped target wins
+func (c *Config) Runlevel(targets string) string {
+	for _, target := range strings.Fields(targets) {
+		if runlevel, ok := c.Runlevels[target]; ok {
+			return runlevel
+		}
+	}
+	return c.DefaultRunlevel
+}
+
+// Format returns the configuration as a TOML document
+func (c *Config) Format() string {
+	var b strings.Builder
+
+	if c.Path != "" {
+		fmt.Fprintf(&b, "# Loaded from %s\n", c.Path)
+	} else {
+		b.WriteString("# Built-in defaults\n")
+	}
+
+	b.WriteString("unit_paths = " + formatList(c.UnitPaths) + "\n")
+	b.WriteString("default_runlevel = " + quote(c.DefaultRunlevel) + "\n")
+	fmt.Fprintf(&b, "systemd_version = %d\n", c.SystemdVersion)
+
+	b.WriteString("\n[runlevels]\n")
+	targets := make([]string, 0, len(c.Runlevels))
+	for target := range c.Runlevels {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		b.WriteString(quote(target) + " = " + quote(c.Runlevels[target]) + "\n")
+	}
+
+	b.WriteString("\n[converter]\n")
+	b.WriteString("depend = " + formatList(c.Converter.Depend) + "\n")
+	b.WriteString("supervisor = " + quote(c.Converter.Supervisor) + "\n")
+	b.WriteString("init_dir = " + quote(c.Converter.InitDir) + "\n")
+	b.WriteString("conf_dir = " + quote(c.Converter.ConfDir) + "\n")
+	b.WriteString("template_dir = " + quote(c.Converter.TemplateDir) + "\n")
+
+	return b.String()
+}
+
+// stringValue checks that a setting is a string
+func stringValue(key string, value any) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", key)
+	}
+	return s, nil
+}
+
+// positiveInt checks that a setting is a positive integer
+func positiveInt(key string, value any) (int64, error) {
+	n, ok := value.(int64)
+	if !ok || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return n, nil
+}
+
+// stringList checks that a setting is an array of strings
+func stringList(key string, value any) ([]string, error) {
+	list, ok := value.([]string)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", key)
+	}
+	return list, nil
+}
+
+// formatList formats strings as a TOML array
+func formatList(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = quote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSupervisor(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false},
+		{"start-stop-daemon", false},
+		{"supervise-daemon", false},
+		{"s6", true},
+		{"runit", true},
+		{"systemd", true},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		content := "[converter]\nsupervisor = " + quote(tt.value) + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); (err != nil) != tt.wantErr {
+			t.Errorf("supervisor %q: error = %v, want error %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
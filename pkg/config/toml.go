@@ -0,0 +1,224 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// document is a parsed TOML document: table name → key → value.
+// Top-level keys live in the table named "".
+type document map[string]map[string]any
+
+// parseTOML parses the subset of TOML used by the configuration file:
+// [tables], bare or quoted keys, strings, booleans, integers and arrays
+// of strings, which may span several lines. Comments start with #.
+func parseTOML(input string) (document, error) {
+	doc := document{"": {}}
+	table := ""
+
+	lines := strings.Split(input, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header %q", lineNo, line)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if _, exists := doc[table]; exists && table != "" {
+				return nil, fmt.Errorf("line %d: table [%s] defined twice", lineNo, table)
+			}
+			doc[table] = map[string]any{}
+			continue
+		}
+
+		rawKey, rawValue, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+
+		key, err := parseKey(strings.TrimSpace(rawKey))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		rawValue = strings.TrimSpace(rawValue)
+
+		// Arrays may continue over several lines until the closing bracket
+		if strings.HasPrefix(rawValue, "[") {
+			for !arrayClosed(rawValue) && i+1 < len(lines) {
+				i++
+				rawValue += " " + strings.TrimSpace(stripComment(lines[i]))
+			}
+		}
+
+		value, err := parseValue(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		if _, exists := doc[table][key]; exists {
+			return nil, fmt.Errorf("line %d: key %q defined twice", lineNo, key)
+		}
+		doc[table][key] = value
+	}
+
+	return doc, nil
+}
+
+// stripComment removes a trailing # comment that is not inside a string
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// arrayClosed reports whether an array value has its closing bracket
+func arrayClosed(value string) bool {
+	return strings.HasSuffix(strings.TrimSpace(value), "]")
+}
+
+// parseKey parses a bare or quoted key
+func parseKey(raw string) (string, error) {
+	if raw == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	if raw[0] == '"' || raw[0] == '\'' {
+		key, rest, err := parseString(raw)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return "", fmt.Errorf("unexpected %q after key", rest)
+		}
+		return key, nil
+	}
+	for _, c := range raw {
+		if !(c == '-' || c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return "", fmt.Errorf("invalid bare key %q (quote keys containing dots)", raw)
+		}
+	}
+	return raw, nil
+}
+
+// parseValue parses a string, boolean, integer or array of strings
+func parseValue(raw string) (any, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("missing value")
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	case raw[0] == '"' || raw[0] == '\'':
+		s, rest, err := parseString(raw)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("unexpected %q after string", rest)
+		}
+		return s, nil
+	case raw[0] == '[':
+		return parseArray(raw)
+	}
+
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported value %q", raw)
+	}
+	return n, nil
+}
+
+// parseArray parses an array of strings such as ["a", "b",]
+func parseArray(raw string) ([]string, error) {
+	rest := strings.TrimSpace(raw[1:])
+	items := []string{}
+
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if rest[0] == ']' {
+			if strings.TrimSpace(rest[1:]) != "" {
+				return nil, fmt.Errorf("unexpected %q after array", rest[1:])
+			}
+			return items, nil
+		}
+
+		item, remaining, err := parseString(rest)
+		if err != nil {
+			return nil, fmt.Errorf("array items must be strings: %w", err)
+		}
+		items = append(items, item)
+
+		remaining = strings.TrimSpace(remaining)
+		if strings.HasPrefix(remaining, ",") {
+			remaining = remaining[1:]
+		} else if !strings.HasPrefix(remaining, "]") {
+			return nil, fmt.Errorf("expected , or ] in array")
+		}
+		rest = remaining
+	}
+}
+
+// parseString parses a basic ("...") or literal ('...') string at the
+// start of raw and returns it with the remaining input
+func parseString(raw string) (string, string, error) {
+	if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+		return "", "", fmt.Errorf("expected a quoted string")
+	}
+
+	quote := raw[0]
+	var out strings.Builder
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+		if c == quote {
+			return out.String(), raw[i+1:], nil
+		}
+		if c != '\\' || quote == '\'' {
+			out.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i >= len(raw) {
+			break
+		}
+		switch raw[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case '"', '\\':
+			out.WriteByte(raw[i])
+		default:
+			return "", "", fmt.Errorf("unsupported escape \\%c", raw[i])
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// quote formats a string as a TOML basic string
+func quote(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  document
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  document{"": {}},
+		},
+		{
+			name:  "basic string",
+			input: `key = "value"`,
+			want:  document{"": {"key": "value"}},
+		},
+		{
+			name:  "escapes",
+			input: `key = "a\tb\nc \"d\" e\\f"`,
+			want:  document{"": {"key": "a\tb\nc \"d\" e\\f"}},
+		},
+		{
+			name:  "literal string keeps backslashes",
+			input: `key = 'C:\path\n'`,
+			want:  document{"": {"key": `C:\path\n`}},
+		},
+		{
+			name:  "booleans",
+			input: "yes = true\nno = false",
+			want:  document{"": {"yes": true, "no": false}},
+		},
+		{
+			name:  "integers",
+			input: "a = 42\nb = -7\nc = +3\nd = 0",
+			want:  document{"": {"a": int64(42), "b": int64(-7), "c": int64(3), "d": int64(0)}},
+		},
+		{
+			name:  "array",
+			input: `list = ["a", 'b', "c,d"]`,
+			want:  document{"": {"list": []string{"a", "b", "c,d"}}},
+		},
+		{
+			name:  "empty array",
+			input: `list = []`,
+			want:  document{"": {"list": []string{}}},
+		},
+		{
+			name:  "multi-line array with comments and trailing comma",
+			input: "list = [\n  \"a\", # first\n  \"b\",\n]\nafter = 1",
+			want:  document{"": {"list": []string{"a", "b"}, "after": int64(1)}},
+		},
+		{
+			name:  "tables",
+			input: "top = 1\n[runlevels]\n\"multi-user.target\" = \"default\"\n[ other ]\nkey = 'x'",
+			want: document{
+				"":          {"top": int64(1)},
+				"runlevels": {"multi-user.target": "default"},
+				"other":     {"key": "x"},
+			},
+		},
+		{
+			name:  "comments",
+			input: "# a comment\nkey = \"a # not a comment\" # a comment\n  # indented\nother = 'b#c'",
+			want:  document{"": {"key": "a # not a comment", "other": "b#c"}},
+		},
+		{
+			name:  "escaped quote before a comment",
+			input: `key = "a\"#b" # comment`,
+			want:  document{"": {"key": `a"#b`}},
+		},
+		{
+			name:  "bare keys",
+			input: "a-b_C9 = 1",
+			want:  document{"": {"a-b_C9": int64(1)}},
+		},
+		{
+			name:  "quoted key",
+			input: `'lit.key' = 2`,
+			want:  document{"": {"lit.key": int64(2)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.input)
+			if err != nil {
+				t.Fatalf("parseTOML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTOML = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing equals", "key", `line 1: expected key = value`},
+		{"missing value", "key =", `line 1: missing value`},
+		{"empty key", "= 1", `line 1: empty key`},
+		{"dotted bare key", "a.b = 1", `line 1: invalid bare key "a.b" (quote keys containing dots)`},
+		{"text after quoted key", `"a" b = 1`, `line 1: unexpected " b" after key`},
+		{"unterminated string", `key = "abc`, `line 1: unterminated string`},
+		{"text after string", `key = "a" b`, `line 1: unexpected " b" after string`},
+		{"unsupported escape", `key = "\x41"`, `line 1: unsupported escape \x`},
+		{"unsupported value", "key = 1.5", `line 1: unsupported value "1.5"`},
+		{"bare word", "key = yes", `line 1: unsupported value "yes"`},
+		{"unterminated array", "key = [\"a\",\n\n", `line 1: unterminated array`},
+		{"unclosed array", "key = [\"a\",\n\"b\"", `line 1: expected , or ] in array`},
+		{"array of integers", "key = [1, 2]", `line 1: array items must be strings: expected a quoted string`},
+		{"missing comma", `key = ["a" "b"]`, `line 1: expected , or ] in array`},
+		{"text after array", `key = ["a"] x]`, `line 1: unexpected " x]" after array`},
+		{"array of arrays", `key = [["a"]]`, `line 1: array items must be strings: expected a quoted string`},
+		{"invalid table header", "[table", `line 1: invalid table header "[table"`},
+		{"array of tables", "[[table]]", `line 1: invalid table header "[[table]]"`},
+		{"table defined twice", "[a]\nx = 1\n[a]", `line 3: table [a] defined twice`},
+		{"key defined twice", "\nkey = 1\nkey = 2", `line 3: key "key" defined twice`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.input)
+			if err == nil {
+				t.Fatalf("parseTOML succeeded, want error %q", tt.want)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("error = %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "plain", "with \"quotes\"", "tab\tand\nnewline", `back\slash`} {
+		doc, err := parseTOML("key = " + quote(s))
+		if err != nil {
+			t.Fatalf("parseTOML(%s): %v", quote(s), err)
+		}
+		if got := doc[""]["key"]; got != s {
+			t.Errorf("round trip of %q = %q", s, got)
+		}
+	}
+}
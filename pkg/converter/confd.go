@@ -0,0 +1,202 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
+)
+
+// ConfFileMarker starts every configuration file written by the converter,
+// so that files installed by packages or administrators are not overwritten
+const ConfFileMarker = "# Generated by systemctl-alpine"
+
+// ulimit describes how a systemd Limit*= setting maps onto ulimit
+type ulimit struct {
+	Key  string
+	Flag string
+	// Unit is the size in bytes of one ulimit unit, or 0 for counts
+	Unit uint64
+}
+
+// ulimits lists the supported Limit*= settings in the order they are
+// written; sizes follow busybox ash, which is /bin/sh on Alpine
+var ulimits = []ulimit{
+	{"LimitCPU", "t", 0},
+	{"LimitFSIZE", "f", 512},
+	{"LimitDATA", "d", 1024},
+	{"LimitSTACK", "s", 1024},
+	{"LimitCORE", "c", 512},
+	{"LimitRSS", "m", 1024},
+	{"LimitNOFILE", "n", 0},
+	{"LimitAS", "v", 1024},
+	{"LimitNPROC", "u", 0},
+	{"LimitMEMLOCK", "l", 1024},
+}
+
+// ulimitArgs converts the Limit*= settings of a unit into the arguments of
+// OpenRC's rc_ulimit. A soft:hard pair sets the two limits separately.
+// Values that cannot be converted, such as time spans, are left out.
+func ulimitArgs(unit parser.UnitKeys) string {
+	var args []string
+
+	for _, limit := range ulimits {
+		value := unit.Get("Service", limit.Key)
+		if value == "" {
+			continue
+		}
+
+		soft, hard, pair := strings.Cut(value, ":")
+		softValue, ok := ulimitValue(soft, limit.Unit)
+		if !ok {
+			continue
+		}
+		if !pair {
+			args = append(args, "-"+limit.Flag+" "+softValue)
+			continue
+		}
+
+		hardValue, ok := ulimitValue(hard, limit.Unit)
+		if !ok {
+			continue
+		}
+		args = append(args, "-S"+limit.Flag+" "+softValue, "-H"+limit.Flag+" "+hardValue)
+	}
+
+	return strings.Join(args, " ")
+}
+
+// ulimitValue converts a systemd limit value into a ulimit value. Sizes
+// accept the K, M, G and T suffixes (base 1024) like systemd does.
+func ulimitValue(value string, unit uint64) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "infinity" {
+		return "unlimited", true
+	}
+
+	factor := uint64(1)
+	if unit > 0 && value != "" {
+		if i := strings.IndexByte("KMGT", value[len(value)-1]); i >= 0 {
+			factor = uint64(1) << (10 * (i + 1))
+			value = value[:len(value)-1]
+		}
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return "", false
+	}
+
+	if unit > 0 {
+		n = n * factor / unit
+	}
+	return strconv.FormatUint(n, 10), true
+}
+
+// GenerateConfFile renders the OpenRC configuration file of a service.
+// OpenRC reads it before the script, so it holds the environment and the
+// settings an administrator may want to override. Environment files are
+// parsed as systemd does and their variables copied into it; a missing
+// file is left to the script's start_pre, so that stop and status still
+// work.
+func GenerateConfFile(data TemplateData) (string, error) {
+	var b strings.Builder
+
+	b.WriteString(ConfFileMarker)
+	if data.SourcePath != "" {
+		b.WriteString(" from " + data.SourcePath)
+	}
+	b.WriteString("\n# Environment and settings for " + filepath.Join(paths.InitDir(), data.Name) + "\n")
+
+	// Environment files take precedence over Environment=, as in systemd
+	if len(data.Environment) > 0 {
+		b.WriteString("\n# Environment=\n")
+		writeExports(&b, data.Environment)
+	}
+
+	for _, file := range data.EnvironmentFiles {
+		prefix := ""
+		if file.Optional {
+			prefix = "-"
+		}
+
+		assignments, err := parser.ReadEnvironmentFile(paths.Resolve(file.Path))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				fmt.Fprintf(&b, "\n# EnvironmentFile=%s%s (not found)\n", prefix, file.Path)
+				continue
+			}
+			return "", fmt.Errorf("EnvironmentFile=%s: %w", file.Path, err)
+		}
+
+		fmt.Fprintf(&b, "\n# EnvironmentFile=%s%s\n", prefix, file.Path)
+		writeExports(&b, assignments)
+	}
+
+	b.WriteString("\n")
+	writeSetting(&b, "command_args", data.CommandArgs)
+	if data.User != "" {
+		user := data.User
+		if data.Group != "" {
+			user += ":" + data.Group
+		}
+		writeSetting(&b, "command_user", user)
+	}
+	writeSetting(&b, "rc_ulimit", data.Ulimit)
+
+	return removeEmptyLines(b.String()), nil
+}
+
+// writeExports writes VAR=value assignments as exported shell variables.
+// Values are single-quoted: systemd does not expand variables in them.
+func writeExports(b *strings.Builder, assignments []string) {
+	for _, assignment := range assignments {
+		name, value, _ := strings.Cut(assignment, "=")
+		b.WriteString("export " + name + "=" + shellQuote(value) + "\n")
+	}
+}
+
+// writeSetting writes an OpenRC setting if it has a value. Values are
+// double-quoted so that $VARIABLE references to the environment expand,
+// as they do in systemd command lines.
+func writeSetting(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`").Replace(value)
+	b.WriteString(name + "=\"" + escaped + "\"\n")
+}
+
+// IsGeneratedConfFile reports whether the configuration file of a service
+// was written by the converter, or does not exist yet
+func IsGeneratedConfFile(serviceName string) bool {
+	content, err := os.ReadFile(paths.ConfFile(serviceName))
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	return strings.HasPrefix(string(content), ConfFileMarker)
+}
+
+// WriteConfFile writes the OpenRC configuration file of a service with the
+// given mode, which also applies to a file that already exists
+func WriteConfFile(content, serviceName string, mode os.FileMode) error {
+	if err := os.MkdirAll(paths.Resolve(paths.ConfDir()), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	path := paths.ConfFile(serviceName)
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write configuration file: %w", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to set mode of configuration file: %w", err)
+	}
+
+	return nil
+}
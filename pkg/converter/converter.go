@@ -4,42 +4,133 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"text/template"
 
 	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
 )
 
-//go:embed openrc.tpl
-var openrcTemplate string
+// Options holds converter settings that are not derived from the unit file
+type Options struct {
+	// Depend lists the entries of the generated depend() function
+	Depend []string
+	// Supervisor is the OpenRC supervisor (empty for start-stop-daemon)
+	Supervisor string
+	// TemplateDir holds template overrides: openrc.tpl for every service
+	// and <name>.tpl for a single service
+	TemplateDir string
+}
+
+// Defaults are the options used by ConvertToOpenRC
+var Defaults = Options{
+	Depend:      []string{"need net", "after firewall"},
+	TemplateDir: "/etc/systemctl-alpine/templates",
+}
 
 // TemplateData holds the data for the OpenRC template
 type TemplateData struct {
-	Name                 string
-	Description          string
-	User                 string
-	Group                string
-	WorkingDirectory     string
-	EnvironmentFile      string
-	Environment          []string
+	// Name is the OpenRC service name, including the instance of templates
+	Name string
+	// Description is the unit's Description=
+	Description string
+	// User and Group are the account the command runs as
+	User  string
+	Group string
+	// WorkingDirectory is the directory the command runs in
+	WorkingDirectory string
+	// EnvironmentFiles are the unit's EnvironmentFile= settings
+	EnvironmentFiles []parser.EnvironmentFile
+	// Environment holds the unit's Environment= assignments as VAR=value
+	Environment []string
+	// ExecStartPreCommands are the ExecStartPre= commands; commands whose
+	// failure is ignored end in "|| true"
 	ExecStartPreCommands []string
-	Command              string
-	CommandArgs          string
-	StopCommand          string
-	Capabilities         string
-	CommandBackground    bool
-	SourcePath           string
-	InstanceName         string
+	// Command and CommandArgs are ExecStart= split into program and arguments
+	Command     string
+	CommandArgs string
+	// StopCommand is the unit's ExecStop=
+	StopCommand string
+	// ReloadCommands are the ExecReload= commands, which run with the main
+	// process in $MAINPID; commands whose failure is ignored end in
+	// "|| true". The script only supports reload when there are any.
+	ReloadCommands []string
+	// Capabilities is AmbientCapabilities= in OpenRC's ^cap_name,... format
+	Capabilities string
+	// Ulimit holds the unit's Limit*= settings as ulimit arguments
+	Ulimit string
+	// CommandBackground is set when OpenRC has to daemonize the command
+	CommandBackground bool
+	// Restart and RestartSec are the unit's restart policy
+	Restart    string
+	RestartSec string
+	// Type is the unit's service type (simple, forking, notify, ...)
+	Type string
+	// SourcePath is the unit file the script was converted from
+	SourcePath string
+	// ConfFile is the OpenRC configuration file holding the environment
+	// and the settings an administrator may override
+	ConfFile string
+	// InstanceName is the instance of a template unit (foo@instance)
+	InstanceName string
+	// Depend lists the entries of the depend() function
+	Depend []string
+	// Supervisor is the OpenRC supervisor (empty for start-stop-daemon)
+	Supervisor string
+	// Unit gives access to every setting of the unit file, for example
+	// {{.Unit.Get "Service" "TimeoutStopSec"}}
+	Unit parser.UnitKeys
+}
+
+// Conversion is the result of converting a systemd service
+type Conversion struct {
+	// Script is the OpenRC init script
+	Script string
+	// ConfFile is the OpenRC configuration file read before the script
+	ConfFile string
+	// ConfMode is the mode of the configuration file, which only root can
+	// read when it holds the variables of environment files
+	ConfMode os.FileMode
+}
+
+// ConvertToOpenRC converts a systemd service to an OpenRC init script and
+// its configuration file
+func ConvertToOpenRC(config *parser.ServiceConfig, serviceName string, instanceName string) (*Conversion, error) {
+	data, err := NewTemplateData(config, serviceName, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the override template for this service, if there is one
+	tmpl, _, err := LoadTemplate(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := RenderTemplate(tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+
+	confFile, err := GenerateConfFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	confMode := os.FileMode(0644)
+	if len(data.EnvironmentFiles) > 0 {
+		confMode = 0600
+	}
+
+	return &Conversion{Script: script, ConfFile: confFile, ConfMode: confMode}, nil
 }
 
-// ConvertToOpenRC converts a systemd service to an OpenRC init script
-func ConvertToOpenRC(config *parser.ServiceConfig, serviceName string, instanceName string) (string, error) {
+// NewTemplateData derives the template data from a parsed systemd service
+func NewTemplateData(config *parser.ServiceConfig, serviceName string, instanceName string) (TemplateData, error) {
 	// Split ExecStart into command and arguments
 	execParts := strings.Fields(config.ExecStart)
 	if len(execParts) == 0 {
-		return "", fmt.Errorf("ExecStart is empty")
+		return TemplateData{}, fmt.Errorf("ExecStart is empty")
 	}
 
 	command := execParts[0]
@@ -48,18 +139,8 @@ func ConvertToOpenRC(config *parser.ServiceConfig, serviceName string, instanceN
 		commandArgs = strings.Join(execParts[1:], " ")
 	}
 
-	// Process ExecStartPre commands
-	var execStartPreCommands []string
-	for _, cmd := range config.ExecStartPre {
-		// Handle commands that might start with - (which means "ignore errors" in systemd)
-		if strings.HasPrefix(cmd, "-") {
-			cmd = strings.TrimPrefix(cmd, "-")
-			// Wrap command in a conditional to ignore errors
-			execStartPreCommands = append(execStartPreCommands, cmd+" || true")
-		} else {
-			execStartPreCommands = append(execStartPreCommands, cmd)
-		}
-	}
+	execStartPreCommands := shellCommands(config.ExecStartPre)
+	reloadCommands := shellCommands(config.ExecReload)
 
 	// Process ExecStop command if present
 	var stopCommand string
@@ -89,50 +170,74 @@ func ConvertToOpenRC(config *parser.ServiceConfig, serviceName string, instanceN
 	}
 	// For Type=simple, Type=notify, or no Type specified, keep commandBackground=true
 
-	// Prepare template data
-	data := TemplateData{
+	// Supervisors other than start-stop-daemon keep the process in the
+	// foreground themselves
+	supervisor := defaultSupervisor()
+	if supervisor != "" {
+		commandBackground = false
+	}
+
+	return TemplateData{
 		Name:                 serviceName,
 		Description:          config.Description,
 		User:                 config.User,
 		Group:                config.Group,
 		WorkingDirectory:     config.WorkingDirectory,
-		EnvironmentFile:      config.EnvironmentFile,
+		EnvironmentFiles:     config.EnvironmentFiles,
 		Environment:          config.Environment,
 		ExecStartPreCommands: execStartPreCommands,
 		Command:              command,
 		CommandArgs:          commandArgs,
 		StopCommand:          stopCommand,
+		ReloadCommands:       reloadCommands,
 		Capabilities:         capabilities,
+		Ulimit:               ulimitArgs(config.Unit),
 		CommandBackground:    commandBackground,
-		SourcePath:           config.SourcePath,
+		Restart:              config.Restart,
+		RestartSec:           config.RestartSec,
+		Type:                 config.Type,
+		SourcePath:           paths.Unresolve(config.SourcePath),
+		ConfFile:             filepath.Join(paths.ConfDir(), serviceName),
 		InstanceName:         instanceName,
-	}
+		Depend:               Defaults.Depend,
+		Supervisor:           supervisor,
+		Unit:                 config.Unit,
+	}, nil
+}
 
-	// Create template
-	tmpl, err := template.New("openrc").Parse(openrcTemplate)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+// shellCommands converts a list of systemd commands such as ExecStartPre=
+// into shell commands. A leading - means "ignore errors" in systemd, so
+// those commands are followed by "|| true".
+func shellCommands(commands []string) []string {
+	var converted []string
+	for _, cmd := range commands {
+		if strings.HasPrefix(cmd, "-") {
+			converted = append(converted, strings.TrimPrefix(cmd, "-")+" || true")
+		} else {
+			converted = append(converted, cmd)
+		}
 	}
+	return converted
+}
 
-	// Render template
-	var output strings.Builder
-	if err := tmpl.Execute(&output, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+// defaultSupervisor returns the configured supervisor; start-stop-daemon
+// is OpenRC's default and needs no setting in the script
+func defaultSupervisor() string {
+	if Defaults.Supervisor == "start-stop-daemon" {
+		return ""
 	}
-
-	// Post-process the output to remove multiple empty lines
-	return removeEmptyLines(output.String()), nil
+	return Defaults.Supervisor
 }
 
 // WriteOpenRCScript writes the OpenRC init script to the appropriate location
 func WriteOpenRCScript(script, serviceName string) error {
 	// Ensure the directory exists
-	if err := os.MkdirAll("/etc/init.d", 0755); err != nil {
+	if err := os.MkdirAll(paths.Resolve(paths.InitDir()), 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	// Write the script
-	path := filepath.Join("/etc/init.d", serviceName)
+	path := paths.InitScript(serviceName)
 	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
 		return fmt.Errorf("failed to write script: %w", err)
 	}
@@ -140,16 +245,6 @@ func WriteOpenRCScript(script, serviceName string) error {
 	return nil
 }
 
-// EnableService adds the service to the default runlevel
-func EnableService(serviceName string) error {
-	cmd := exec.Command("rc-update", "add", serviceName, "default")
-	if err := cmd.Run(); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // removeEmptyLines removes multiple consecutive empty lines and trims space
 func removeEmptyLines(input string) string {
 	// Split into lines
@@ -8,46 +8,47 @@
 export INSTANCE="{{.InstanceName}}"
 {{end}}
 
-{{if .EnvironmentFile}}
-# Source environment file if it exists
-if [ -f "{{.EnvironmentFile}}" ]; then
-	export $(grep -v '^#' "{{.EnvironmentFile}}" | xargs)
-fi
-{{end}}
-
-{{range .Environment}}
-export {{.}}
+# Environment, command_args, command_user and rc_ulimit are set in {{.ConfFile}}
+{{if ne .ConfFile (printf "/etc/conf.d/%s" .Name)}}
+[ -f "{{.ConfFile}}" ] && . "{{.ConfFile}}"
 {{end}}
 
 name="${RC_SVCNAME:-{{.Name}}}"
 description="{{.Description}}"
-{{if .User}}
-command_user="{{.User}}{{if .Group}}:{{.Group}}{{end}}"
-{{end}}
 {{if .WorkingDirectory}}
 directory="{{.WorkingDirectory}}"
 {{end}}
+{{if .Supervisor}}
+supervisor={{.Supervisor}}
+{{end}}
 {{if .CommandBackground}}
 command_background=true
 {{end}}
 
 command="{{.Command}}"
-{{if .CommandArgs}}
-command_args="{{.CommandArgs}}"
-{{end}}
 
 pidfile="/run/$name/$name.pid"
+{{if .ReloadCommands}}
+extra_started_commands="reload"
+{{end}}
 
 {{if .Capabilities}}
 capabilities="{{.Capabilities}}"
 {{end}}
 
 depend() {
-    need net
-    after firewall
+{{- range .Depend}}
+    {{.}}
+{{- end}}
 }
 
 start_pre() {
+{{- range .EnvironmentFiles}}{{if not .Optional}}
+    if [ ! -f "{{.Path}}" ]; then
+        eerror "EnvironmentFile {{.Path}} does not exist"
+        return 1
+    fi
+{{- end}}{{end}}
     checkpath --directory --owner $command_user --mode 0755 ${pidfile%/*}
 {{range .ExecStartPreCommands}}
     {{.}}
@@ -62,8 +63,21 @@ stop() {
 }
 {{end}}
 
+{{if .ReloadCommands}}
 reload() {
     ebegin "Reloading $RC_SVCNAME configuration"
-    start_pre && start-stop-daemon --signal HUP --pidfile $pidfile
+{{- if eq .Supervisor "supervise-daemon"}}
+    MAINPID=$(service_get_value child_pid)
+{{- else}}
+    MAINPID=$(cat "$pidfile" 2>/dev/null)
+{{- end}}
+    export MAINPID
+    (
+        set -e
+{{- range .ReloadCommands}}
+        {{.}}
+{{- end}}
+    )
     eend $?
 }
+{{end}}
\ No newline at end of file
@@ -0,0 +1,188 @@
+package converter
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"systemctl-alpine/pkg/parser"
+	"systemctl-alpine/pkg/paths"
+)
+
+//go:embed openrc.tpl
+var openrcTemplate string
+
+// BuiltinTemplate is the name reported for the embedded template
+const BuiltinTemplate = "built-in"
+
+// globalTemplate is the name of the override used for every service
+const globalTemplate = "openrc.tpl"
+
+// openrcShebang is the interpreter line every OpenRC script starts with
+const openrcShebang = "#!/sbin/openrc-run"
+
+// templateFuncs are the helper functions available to templates
+var templateFuncs = template.FuncMap{
+	// shquote quotes a value for the shell: {{shquote .Description}}
+	"shquote": shellQuote,
+	// join joins a list: {{join " " .Depend}}
+	"join": func(sep string, list []string) string {
+		return strings.Join(list, sep)
+	},
+	// default replaces an empty value: {{.User | default "root"}}
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// TemplatePath returns the override template used for a service, or ""
+// when the built-in template applies. A per-service <name>.tpl wins over
+// <template>@.tpl for instances, which wins over the global openrc.tpl.
+func TemplatePath(serviceName string) string {
+	dir := paths.Resolve(Defaults.TemplateDir)
+
+	candidates := []string{serviceName + ".tpl"}
+	if prefix, _, ok := strings.Cut(serviceName, "@"); ok {
+		candidates = append(candidates, prefix+"@.tpl")
+	}
+	candidates = append(candidates, globalTemplate)
+
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// LoadTemplate parses the template used for a service and returns it
+// together with the file it was read from, or BuiltinTemplate
+func LoadTemplate(serviceName string) (*template.Template, string, error) {
+	path := TemplatePath(serviceName)
+	if path == "" {
+		tmpl, err := ParseBuiltinTemplate()
+		return tmpl, BuiltinTemplate, err
+	}
+
+	tmpl, err := ParseTemplateFile(path)
+	return tmpl, path, err
+}
+
+// ParseBuiltinTemplate parses the embedded template
+func ParseBuiltinTemplate() (*template.Template, error) {
+	return ParseTemplate(BuiltinTemplate, openrcTemplate)
+}
+
+// ParseTemplateFile parses an override template from a file
+func ParseTemplateFile(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return ParseTemplate(path, string(content))
+}
+
+// ParseTemplate parses a template with the helper functions. The built-in
+// template is available to overrides as {{template "default" .}}, so that
+// an override can extend the generated script instead of replacing it.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	tmpl := template.New(name).Funcs(templateFuncs).Option("missingkey=error")
+
+	if _, err := tmpl.New("default").Parse(openrcTemplate); err != nil {
+		return nil, fmt.Errorf("failed to parse built-in template: %w", err)
+	}
+	if _, err := tmpl.Parse(text); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// RenderTemplate executes a template and tidies the resulting script
+func RenderTemplate(tmpl *template.Template, data TemplateData) (string, error) {
+	var output strings.Builder
+	if err := tmpl.Execute(&output, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	// Post-process the output to remove multiple empty lines
+	return removeEmptyLines(output.String()), nil
+}
+
+// CheckTemplate renders a template with data and verifies that the result
+// looks like an OpenRC script
+func CheckTemplate(tmpl *template.Template, data TemplateData) (string, error) {
+	script, err := RenderTemplate(tmpl, data)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(script, openrcShebang) {
+		return "", fmt.Errorf("rendered script does not start with %s", openrcShebang)
+	}
+
+	return script, nil
+}
+
+// SampleData returns template data with every field set, used to check
+// templates without a unit file
+func SampleData() TemplateData {
+	unit := parser.UnitKeys{
+		"Unit": {
+			"Description": {"Example service"},
+		},
+		"Service": {
+			"Type":         {"simple"},
+			"User":         {"example"},
+			"ExecStart":    {"/usr/bin/example --config /etc/example.conf"},
+			"ExecStartPre": {"/usr/bin/example --check"},
+			"ExecReload":   {"/bin/kill -HUP $MAINPID"},
+			"Environment":  {"EXAMPLE_MODE=production"},
+			"LimitNOFILE":  {"65536"},
+			"Restart":      {"on-failure"},
+			"RestartSec":   {"5"},
+		},
+		"Install": {
+			"WantedBy": {"multi-user.target"},
+		},
+	}
+
+	return TemplateData{
+		Name:                 "example",
+		Description:          "Example service",
+		User:                 "example",
+		Group:                "example",
+		WorkingDirectory:     "/var/lib/example",
+		EnvironmentFiles:     []parser.EnvironmentFile{{Path: "/etc/default/example", Optional: true}},
+		Environment:          []string{"EXAMPLE_MODE=production"},
+		ExecStartPreCommands: []string{"/usr/bin/example --check"},
+		Command:              "/usr/bin/example",
+		CommandArgs:          "--config /etc/example.conf",
+		StopCommand:          "/usr/bin/example --stop",
+		ReloadCommands:       []string{"/bin/kill -HUP $MAINPID"},
+		Capabilities:         "^cap_net_bind_service",
+		Ulimit:               "-n 65536",
+		CommandBackground:    true,
+		Restart:              "on-failure",
+		RestartSec:           "5",
+		Type:                 "simple",
+		SourcePath:           "/lib/systemd/system/example.service",
+		ConfFile:             "/etc/conf.d/example",
+		Depend:               Defaults.Depend,
+		Supervisor:           defaultSupervisor(),
+		Unit:                 unit,
+	}
+}
+
+// shellQuote quotes a value with single quotes for the shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
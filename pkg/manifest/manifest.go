@@ -0,0 +1,182 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"systemctl-alpine/pkg/paths"
+	"systemctl-alpine/pkg/util"
+)
+
+// FileName is the name of the manifest in the state directory
+const FileName = "conversions.json"
+
+// formatVersion is the version of the manifest format
+const formatVersion = 1
+
+// File is a file a conversion was made from
+type File struct {
+	Path string `json:"path"`
+	// SHA256 is the checksum of the file, or "" if it did not exist
+	SHA256 string `json:"sha256"`
+}
+
+// Entry records the conversion of one service
+type Entry struct {
+	// Service is the OpenRC service name
+	Service string `json:"service"`
+	// Unit is the unit file the service was converted from
+	Unit string `json:"unit"`
+	// Instance is the instance name of a template unit
+	Instance string `json:"instance,omitempty"`
+	// Sources are the unit file, its drop-ins, environment files and the
+	// template, with their checksums at conversion time
+	Sources []File `json:"sources"`
+	// ToolVersion is the version of systemctl-alpine that converted the unit
+	ToolVersion string `json:"tool_version"`
+	// ScriptSHA256 is the checksum of the generated script, without edits
+	// merged into it, and ConfSHA256 that of the configuration file written
+	ScriptSHA256 string `json:"script_sha256"`
+	ConfSHA256   string `json:"conf_sha256,omitempty"`
+	// ConvertedAt is the time of the conversion
+	ConvertedAt time.Time `json:"converted_at"`
+}
+
+// Manifest records the conversions made by the tool, so that changed
+// unit files can be converted again by daemon-reload
+type Manifest struct {
+	Version int               `json:"version"`
+	Units   map[string]*Entry `json:"units"`
+}
+
+// Path returns the location of the manifest
+func Path() string {
+	return paths.StateFile(FileName)
+}
+
+// Load reads the manifest; a missing manifest is empty
+func Load() (*Manifest, error) {
+	m := &Manifest{Version: formatVersion, Units: map[string]*Entry{}}
+
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", Path(), err)
+	}
+	if m.Units == nil {
+		m.Units = map[string]*Entry{}
+	}
+
+	return m, nil
+}
+
+// Save writes the manifest atomically
+func (m *Manifest) Save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if err := util.WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// Services returns the names of the recorded services in sorted order
+func (m *Manifest) Services() []string {
+	names := make([]string, 0, len(m.Units))
+	for name := range m.Units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Checksum returns the SHA-256 checksum of data as a hex string
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFiles returns the checksums of files. Files that do not exist get an
+// empty checksum, so that their later creation is noticed.
+func HashFiles(files []string) ([]File, error) {
+	hashed := make([]File, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(paths.Resolve(file))
+		switch {
+		case err == nil:
+			hashed = append(hashed, File{Path: file, SHA256: Checksum(data)})
+		case os.IsNotExist(err):
+			hashed = append(hashed, File{Path: file})
+		default:
+			return nil, err
+		}
+	}
+	return hashed, nil
+}
+
+// ChangedSources returns the files that were added, removed or modified
+// between two lists of sources
+func ChangedSources(recorded, current []File) []string {
+	before := make(map[string]string, len(recorded))
+	for _, file := range recorded {
+		before[file.Path] = file.SHA256
+	}
+
+	var changed []string
+	for _, file := range current {
+		if sum, ok := before[file.Path]; !ok || sum != file.SHA256 {
+			changed = append(changed, file.Path)
+		}
+		delete(before, file.Path)
+	}
+	for path := range before {
+		changed = append(changed, path)
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// pristineDir is the directory in the state directory holding the scripts
+// as they were generated, before any edits
+const pristineDir = "pristine"
+
+// ReadPristine returns the script of a service as it was last generated
+func ReadPristine(service string) (string, error) {
+	data, err := os.ReadFile(paths.StateFile(filepath.Join(pristineDir, service)))
+	return string(data), err
+}
+
+// WritePristine keeps a copy of a generated script, which is the base of
+// three-way merges when the script is generated again after being edited
+func WritePristine(service, script string) error {
+	path := paths.StateFile(filepath.Join(pristineDir, service))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := util.WriteFileAtomic(path, []byte(script), 0644); err != nil {
+		return fmt.Errorf("failed to write pristine script: %w", err)
+	}
+	return nil
+}
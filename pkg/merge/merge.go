@@ -0,0 +1,119 @@
+package merge
+
+import (
+	"slices"
+	"strings"
+)
+
+// Labels name the three versions in conflict markers
+type Labels struct {
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// ThreeWay merges the changes from base to ours and from base to theirs,
+// line by line, in the manner of diff3. Where both sides changed the same
+// lines differently, conflict markers are inserted and conflict is true.
+func ThreeWay(base, ours, theirs string, labels Labels) (merged string, conflict bool) {
+	baseLines := splitLines(base)
+	ourLines := splitLines(ours)
+	theirLines := splitLines(theirs)
+
+	toOurs := matchLines(baseLines, ourLines)
+	toTheirs := matchLines(baseLines, theirLines)
+
+	var out []string
+	i, j, k := 0, 0, 0
+	for i < len(baseLines) || j < len(ourLines) || k < len(theirLines) {
+		// Lines unchanged on both sides are copied
+		if i < len(baseLines) && toOurs[i] == j && toTheirs[i] == k {
+			out = append(out, baseLines[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// Otherwise find the next base line kept on both sides; the lines
+		// before it form a chunk that changed on at least one side
+		m := i
+		for m < len(baseLines) && (toOurs[m] < 0 || toTheirs[m] < 0) {
+			m++
+		}
+		nextOurs, nextTheirs := len(ourLines), len(theirLines)
+		if m < len(baseLines) {
+			nextOurs, nextTheirs = toOurs[m], toTheirs[m]
+		}
+
+		baseChunk := baseLines[i:m]
+		ourChunk := ourLines[j:nextOurs]
+		theirChunk := theirLines[k:nextTheirs]
+
+		switch {
+		case slices.Equal(ourChunk, baseChunk):
+			out = append(out, theirChunk...)
+		case slices.Equal(theirChunk, baseChunk), slices.Equal(ourChunk, theirChunk):
+			out = append(out, ourChunk...)
+		default:
+			conflict = true
+			out = append(out, "<<<<<<< "+labels.Ours)
+			out = append(out, ourChunk...)
+			out = append(out, "||||||| "+labels.Base)
+			out = append(out, baseChunk...)
+			out = append(out, "=======")
+			out = append(out, theirChunk...)
+			out = append(out, ">>>>>>> "+labels.Theirs)
+		}
+
+		i, j, k = m, nextOurs, nextTheirs
+	}
+
+	if len(out) == 0 {
+		return "", conflict
+	}
+	return strings.Join(out, "\n") + "\n", conflict
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// matchLines aligns b with a using a longest common subsequence and returns,
+// for every line of a, the index of the matching line of b or -1
+func matchLines(a, b []string) []int {
+	// lengths[i][j] is the length of the LCS of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	match := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		switch {
+		case j < len(b) && a[i] == b[j]:
+			match[i] = j
+			i, j = i+1, j+1
+		case j < len(b) && lengths[i][j+1] >= lengths[i+1][j]:
+			j++
+		default:
+			match[i] = -1
+			i++
+		}
+	}
+
+	return match
+}
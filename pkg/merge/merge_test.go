@@ -0,0 +1,137 @@
+package merge
+
+import (
+	"slices"
+	"testing"
+)
+
+var testLabels = Labels{Base: "base", Ours: "ours", Theirs: "theirs"}
+
+func TestThreeWay(t *testing.T) {
+	tests := []struct {
+		name         string
+		base         string
+		ours         string
+		theirs       string
+		want         string
+		wantConflict bool
+	}{
+		{
+			name: "no changes",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nb\nc\n",
+		},
+		{
+			name: "change on our side only",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "change on their side only",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nC\n",
+			want: "a\nb\nC\n",
+		},
+		{
+			name: "changes to different lines",
+			base: "a\nb\nc\nd\ne\n", ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n",
+			want: "A\nb\nc\nd\nE\n",
+		},
+		{
+			name: "insertion and deletion",
+			base: "a\nb\nc\nd\n", ours: "a\nnew\nb\nc\nd\n", theirs: "a\nb\nc\n",
+			want: "a\nnew\nb\nc\n",
+		},
+		{
+			name: "identical changes on both sides",
+			base: "a\nb\nc\n", ours: "a\nX\nc\n", theirs: "a\nX\nc\n",
+			want: "a\nX\nc\n",
+		},
+		{
+			name: "identical deletions on both sides",
+			base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nc\n",
+			want: "a\nc\n",
+		},
+		{
+			name: "conflicting changes",
+			base: "a\nb\nc\n", ours: "a\nours\nc\n", theirs: "a\ntheirs\nc\n",
+			want:         "a\n<<<<<<< ours\nours\n||||||| base\nb\n=======\ntheirs\n>>>>>>> theirs\nc\n",
+			wantConflict: true,
+		},
+		{
+			name: "overlapping changes of different lengths",
+			base: "a\nb\nc\nd\n", ours: "a\nB\nC\nd\n", theirs: "a\nb\nX\nY\nZ\nd\n",
+			want:         "a\n<<<<<<< ours\nB\nC\n||||||| base\nb\nc\n=======\nb\nX\nY\nZ\n>>>>>>> theirs\nd\n",
+			wantConflict: true,
+		},
+		{
+			name: "change against deletion",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nc\n",
+			want:         "a\n<<<<<<< ours\nB\n||||||| base\nb\n=======\n>>>>>>> theirs\nc\n",
+			wantConflict: true,
+		},
+		{
+			name: "different insertions at the end",
+			base: "a\n", ours: "a\nx\n", theirs: "a\ny\n",
+			want:         "a\n<<<<<<< ours\nx\n||||||| base\n=======\ny\n>>>>>>> theirs\n",
+			wantConflict: true,
+		},
+		{
+			name: "a conflict and a clean change",
+			base: "a\nb\nc\nd\n", ours: "A\nb\nc\nours\n", theirs: "a\nb\nc\ntheirs\n",
+			want:         "A\nb\nc\n<<<<<<< ours\nours\n||||||| base\nd\n=======\ntheirs\n>>>>>>> theirs\n",
+			wantConflict: true,
+		},
+		{
+			name: "all empty",
+			want: "",
+		},
+		{
+			name: "empty base with identical additions",
+			ours: "a\nb\n", theirs: "a\nb\n",
+			want: "a\nb\n",
+		},
+		{
+			name: "empty base with one addition",
+			ours: "a\n",
+			want: "a\n",
+		},
+		{
+			name: "empty base with different additions",
+			ours: "a\n", theirs: "b\n",
+			want:         "<<<<<<< ours\na\n||||||| base\n=======\nb\n>>>>>>> theirs\n",
+			wantConflict: true,
+		},
+		{
+			name: "everything deleted on one side",
+			base: "a\nb\n", ours: "", theirs: "a\nb\n",
+			want: "",
+		},
+		{
+			name: "missing final newline",
+			base: "a\nb", ours: "a\nb", theirs: "a\nB",
+			want: "a\nB\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflict := ThreeWay(tt.base, tt.ours, tt.theirs, testLabels)
+			if got != tt.want {
+				t.Errorf("merged:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if conflict != tt.wantConflict {
+				t.Errorf("conflict = %v, want %v", conflict, tt.wantConflict)
+			}
+		})
+	}
+}
+
+func TestMatchLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"x", "a", "c", "d", "y"}
+	want := []int{1, -1, 2, 3}
+
+	if got := matchLines(a, b); !slices.Equal(got, want) {
+		t.Errorf("matchLines = %v, want %v", got, want)
+	}
+}
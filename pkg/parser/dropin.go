@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"systemctl-alpine/pkg/paths"
+)
+
+// DropInFiles returns the drop-ins (<unit>.d/*.conf) of a unit such as
+// foo.service, sorted by file name. A drop-in in a unit directory of
+// higher precedence hides one with the same name in a lower one. Instances
+// of template units also use the drop-ins of the template, unless the
+// instance has a drop-in with the same name.
+func DropInFiles(unitName string) []string {
+	dirNames := []string{unitName + ".d"}
+	if prefix, rest, ok := strings.Cut(unitName, "@"); ok && !strings.HasPrefix(rest, ".") {
+		template := prefix + "@" + rest[strings.LastIndex(rest, "."):]
+		dirNames = append(dirNames, template+".d")
+	}
+
+	found := map[string]string{}
+	for _, location := range paths.UnitDirs() {
+		for _, dirName := range dirNames {
+			entries, err := os.ReadDir(filepath.Join(location, dirName))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if entry.IsDir() || !strings.HasSuffix(name, ".conf") {
+					continue
+				}
+				if _, exists := found[name]; !exists {
+					found[name] = filepath.Join(location, dirName, name)
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]string, len(names))
+	for i, name := range names {
+		files[i] = found[name]
+	}
+	return files
+}
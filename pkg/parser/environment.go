@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvironmentFile is an EnvironmentFile= setting of a unit
+type EnvironmentFile struct {
+	Path string
+	// Optional is set by a leading "-": a missing file is not an error
+	Optional bool
+}
+
+// splitEnvironment splits the value of an Environment= setting into its
+// VAR=value assignments. Assignments are separated by whitespace and may be
+// quoted as a whole, e.g. "VAR1=word1 word2" VAR2=word3.
+func splitEnvironment(value string) []string {
+	var assignments []string
+	var current strings.Builder
+	var quote rune
+	inWord := false
+
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == '"' && c == '\\' && i+1 < len(runes):
+			i++
+			current.WriteRune(runes[i])
+		case quote != 0:
+			current.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				assignments = append(assignments, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		assignments = append(assignments, current.String())
+	}
+
+	// Like systemd, ignore assignments that are not VAR=value
+	valid := assignments[:0]
+	for _, assignment := range assignments {
+		if name, _, ok := strings.Cut(assignment, "="); ok && isEnvironmentName(name) {
+			valid = append(valid, assignment)
+		}
+	}
+
+	return valid
+}
+
+// ReadEnvironmentFile reads the VAR=value assignments of an environment file
+func ReadEnvironmentFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment file: %w", err)
+	}
+	return parseEnvironmentFile(string(content)), nil
+}
+
+// parseEnvironmentFile parses an environment file the way systemd does:
+// lines starting with # or ; are comments, values may be single-quoted
+// (literal), double-quoted (with \ escapes) or unquoted (with \ escapes
+// and trailing whitespace removed), and a trailing \ continues a line.
+// Lines that are not VAR=value are ignored.
+func parseEnvironmentFile(content string) []string {
+	var assignments []string
+
+	runes := []rune(strings.ReplaceAll(content, "\r\n", "\n"))
+	i := 0
+	for i < len(runes) {
+		// Skip leading whitespace and blank lines
+		for i < len(runes) && strings.ContainsRune(" \t\n", runes[i]) {
+			i++
+		}
+		if i >= len(runes) {
+			break
+		}
+
+		// Comments run until the end of the line
+		if runes[i] == '#' || runes[i] == ';' {
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		// The name runs until = or the end of the line
+		start := i
+		for i < len(runes) && runes[i] != '=' && runes[i] != '\n' {
+			i++
+		}
+		if i >= len(runes) || runes[i] != '=' {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(string(runes[start:i])), "export ")
+		name = strings.TrimSpace(name)
+		i++
+
+		value, next := parseEnvironmentValue(runes, i)
+		i = next
+
+		if isEnvironmentName(name) {
+			assignments = append(assignments, name+"="+value)
+		}
+	}
+
+	return assignments
+}
+
+// parseEnvironmentValue parses the value that starts at runes[i] and
+// returns it with the position after the end of its line
+func parseEnvironmentValue(runes []rune, i int) (string, int) {
+	var value strings.Builder
+	// keep is the length of the value without trailing unquoted whitespace
+	keep := 0
+	var quote rune
+
+	// Leading whitespace is not part of the value
+	for i < len(runes) && (runes[i] == ' ' || runes[i] == '\t') {
+		i++
+	}
+
+	for ; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				value.WriteRune(c)
+			}
+			keep = value.Len()
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else if c == '\\' && i+1 < len(runes) {
+				i++
+				switch runes[i] {
+				case '\n':
+					// Line continuation
+				case '"', '\\', '`', '$':
+					value.WriteRune(runes[i])
+				default:
+					value.WriteRune('\\')
+					value.WriteRune(runes[i])
+				}
+			} else {
+				value.WriteRune(c)
+			}
+			keep = value.Len()
+		case c == '\n':
+			return value.String()[:keep], i + 1
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] != '\n' {
+				value.WriteRune(runes[i])
+				keep = value.Len()
+			}
+		default:
+			value.WriteRune(c)
+			if c != ' ' && c != '\t' {
+				keep = value.Len()
+			}
+		}
+	}
+
+	return value.String()[:keep], i
+}
+
+// isEnvironmentName reports whether name is a valid environment variable name
+func isEnvironmentName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
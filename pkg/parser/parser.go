@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
+	"systemctl-alpine/pkg/paths"
 	"systemctl-alpine/pkg/util"
 )
 
@@ -17,33 +19,97 @@ type ServiceConfig struct {
 	User                string
 	Group               string
 	WorkingDirectory    string
-	EnvironmentFile     string
+	EnvironmentFiles    []EnvironmentFile
 	Environment         []string
 	ExecStartPre        []string
 	ExecStart           string
 	ExecStop            string
+	ExecReload          []string
 	Restart             string
 	RestartSec          string
 	WantedBy            string
+	RequiredBy          string
+	Alias               string
+	Also                string
 	AmbientCapabilities string
 	Type                string
 	SourcePath          string
+	// DropInPaths are the drop-ins applied on top of the unit file
+	DropInPaths []string
+	// Unit holds every setting of the unit file, including those without
+	// a field above, for use by OpenRC script templates
+	Unit UnitKeys
 }
 
-// ParseServiceFile parses a systemd service file and returns a ServiceConfig
-func ParseServiceFile(path string, instanceName string) (*ServiceConfig, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open service file: %w", err)
+// UnitKeys maps section → key → values in file order; keys that may be
+// repeated (Environment=, ExecStartPre=, ...) keep every value
+type UnitKeys map[string]map[string][]string
+
+// Get returns the last value of a key, which is the one systemd uses for
+// settings that are not lists, or "" if the key is not set
+func (u UnitKeys) Get(section, key string) string {
+	values := u[section][key]
+	if len(values) == 0 {
+		return ""
 	}
-	defer file.Close()
+	return values[len(values)-1]
+}
+
+// All returns every value of a key
+func (u UnitKeys) All(section, key string) []string {
+	return u[section][key]
+}
 
+// add records a value of a key; an empty value resets the key, as it
+// does in systemd
+func (u UnitKeys) add(section, key, value string) {
+	if u[section] == nil {
+		u[section] = map[string][]string{}
+	}
+	if value == "" {
+		u[section][key] = nil
+		return
+	}
+	u[section][key] = append(u[section][key], value)
+}
+
+// ParseServiceFile parses a systemd service file and its drop-ins and
+// returns a ServiceConfig
+func ParseServiceFile(path string, instanceName string) (*ServiceConfig, error) {
 	name := filepath.Base(path)
-	name = util.NormalizeServiceName(name)
 
 	config := &ServiceConfig{
 		SourcePath: path,
+		Unit:       UnitKeys{},
+	}
+
+	if err := config.parseFile(path, util.NormalizeServiceName(name), instanceName); err != nil {
+		return nil, err
+	}
+
+	// Drop-ins of an instance include those of the template
+	unitName := name
+	if instanceName != "" {
+		unitName = strings.Replace(name, "@.", "@"+instanceName+".", 1)
+	}
+	config.DropInPaths = DropInFiles(unitName)
+	for _, dropIn := range config.DropInPaths {
+		if err := config.parseFile(dropIn, util.NormalizeServiceName(name), instanceName); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// parseFile applies the settings of a unit file or drop-in to the config
+func (config *ServiceConfig) parseFile(path, name, instanceName string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open service file: %w", err)
 	}
+	defer file.Close()
+
 	scanner := bufio.NewScanner(file)
 
 	var section string
@@ -76,6 +142,8 @@ func ParseServiceFile(path string, instanceName string) (*ServiceConfig, error)
 			value = ProcessTemplateSubstitutions(value, name, instanceName)
 		}
 
+		config.Unit.add(section, key, value)
+
 		switch section {
 		case "Unit":
 			if key == "Description" {
@@ -90,23 +158,42 @@ func ParseServiceFile(path string, instanceName string) (*ServiceConfig, error)
 			case "WorkingDirectory":
 				config.WorkingDirectory = value
 			case "EnvironmentFile":
-				// Remove the leading dash if present (indicates optional)
-				if strings.HasPrefix(value, "-") {
-					value = strings.TrimPrefix(value, "-")
+				// An empty value resets the list
+				if value == "" {
+					config.EnvironmentFiles = nil
+					continue
 				}
-				config.EnvironmentFile = value
+				// A leading dash marks the file as optional
+				optional := strings.HasPrefix(value, "-")
+				config.EnvironmentFiles = append(config.EnvironmentFiles, EnvironmentFile{
+					Path:     strings.TrimPrefix(value, "-"),
+					Optional: optional,
+				})
 			case "Environment":
-				// Remove quotes if present
-				if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-					value = value[1 : len(value)-1]
+				// An empty value resets the list
+				if value == "" {
+					config.Environment = nil
+					continue
 				}
-				config.Environment = append(config.Environment, value)
+				config.Environment = append(config.Environment, splitEnvironment(value)...)
 			case "ExecStartPre":
+				// An empty value resets the list
+				if value == "" {
+					config.ExecStartPre = nil
+					continue
+				}
 				config.ExecStartPre = append(config.ExecStartPre, value)
 			case "ExecStart":
 				config.ExecStart = value
 			case "ExecStop":
 				config.ExecStop = value
+			case "ExecReload":
+				// An empty value resets the list
+				if value == "" {
+					config.ExecReload = nil
+					continue
+				}
+				config.ExecReload = append(config.ExecReload, value)
 			case "Restart":
 				config.Restart = value
 			case "RestartSec":
@@ -117,17 +204,30 @@ func ParseServiceFile(path string, instanceName string) (*ServiceConfig, error)
 				config.Type = value
 			}
 		case "Install":
-			if key == "WantedBy" {
+			switch key {
+			case "WantedBy":
 				config.WantedBy = value
+			case "RequiredBy":
+				config.RequiredBy = value
+			case "Alias":
+				config.Alias = value
+			case "Also":
+				config.Also = value
 			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading service file: %w", err)
+		return fmt.Errorf("error reading service file: %w", err)
 	}
 
-	return config, nil
+	return nil
+}
+
+// HasInstallSection reports whether the unit can be enabled, i.e. whether
+// its [Install] section declares anything
+func (c *ServiceConfig) HasInstallSection() bool {
+	return c.WantedBy != "" || c.RequiredBy != "" || c.Alias != "" || c.Also != ""
 }
 
 // ProcessTemplateSubstitutions replaces template specifiers in a string with their values
@@ -157,13 +257,13 @@ func ProcessTemplateSubstitutions(input string, unitName string, instanceName st
 
 	// Get machine ID if available
 	machineID := ""
-	if data, err := os.ReadFile("/etc/machine-id"); err == nil {
+	if data, err := os.ReadFile(paths.Resolve("/etc/machine-id")); err == nil {
 		machineID = strings.TrimSpace(string(data))
 	}
 
 	// Get OS ID if available
 	osID := ""
-	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+	if data, err := os.ReadFile(paths.Resolve("/etc/os-release")); err == nil {
 		for _, line := range strings.Split(string(data), "\n") {
 			if strings.HasPrefix(line, "ID=") {
 				osID = strings.Trim(strings.TrimPrefix(line, "ID="), "\"")
@@ -197,3 +297,46 @@ func unescapeValue(value string) string {
 	result = strings.ReplaceAll(result, "\\x2d", "-")
 	return result
 }
+
+// knownSections are the sections of a service unit file
+var knownSections = []string{"Unit", "Service", "Install"}
+
+// Validate checks the syntax of a unit file or drop-in: every line is a
+// comment, a known [Section] header or a Key=value assignment inside a
+// section. Sections starting with X- are allowed for extensions.
+func Validate(content string) error {
+	var section string
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("line %d: invalid section header %q", lineNo, line)
+			}
+			section = line[1 : len(line)-1]
+			if !slices.Contains(knownSections, section) && !strings.HasPrefix(section, "X-") {
+				return fmt.Errorf("line %d: unknown section [%s]", lineNo, section)
+			}
+			continue
+		}
+
+		key, _, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected Key=value, got %q", lineNo, line)
+		}
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("line %d: missing key before =", lineNo)
+		}
+		if section == "" {
+			return fmt.Errorf("line %d: assignment outside of a section", lineNo)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package paths
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Well-known locations, as seen from inside the root directory
+const (
+	RunlevelsDir = "/etc/runlevels"
+	OpenRCRunDir = "/run/openrc"
+	LogDir       = "/var/log"
+	StateDir     = "/var/lib/systemctl-alpine"
+	// AdminUnitDir holds unit files and drop-ins created by the administrator
+	AdminUnitDir = "/etc/systemd/system"
+	// RuntimeUnitDir holds unit files and drop-ins that last until reboot
+	RuntimeUnitDir = "/run/systemd/system"
+)
+
+var (
+	// unitDirs are the systemd unit file locations, in order of precedence,
+	// set from the configuration
+	unitDirs []string
+
+	// initDir is where OpenRC init scripts live
+	initDir = "/etc/init.d"
+
+	// confDir is where OpenRC service configuration files live
+	confDir = "/etc/conf.d"
+)
+
+// root is the directory all paths are resolved against (--root)
+var root = "/"
+
+// SetRoot sets the directory that all paths are resolved against
+func SetRoot(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	root = abs
+	return nil
+}
+
+// Root returns the directory that all paths are resolved against
+func Root() string {
+	return root
+}
+
+// IsRooted reports whether paths are resolved against a directory other than /
+func IsRooted() bool {
+	return root != "/"
+}
+
+// SetUnitDirs sets the systemd unit file locations, in order of precedence
+func SetUnitDirs(dirs []string) {
+	unitDirs = dirs
+}
+
+// SetInitDir sets the directory of OpenRC init scripts
+func SetInitDir(dir string) {
+	initDir = dir
+}
+
+// SetConfDir sets the directory of OpenRC service configuration files
+func SetConfDir(dir string) {
+	confDir = dir
+}
+
+// InitDir returns the directory of OpenRC init scripts, as seen from inside the root
+func InitDir() string {
+	return initDir
+}
+
+// ConfDir returns the directory of OpenRC service configuration files,
+// as seen from inside the root
+func ConfDir() string {
+	return confDir
+}
+
+// Resolve returns the location of an absolute path inside the root directory
+func Resolve(path string) string {
+	if root == "/" {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+// Unresolve returns the path a resolved location has inside the root directory
+func Unresolve(path string) string {
+	if root == "/" {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return path
+	}
+	return "/" + rel
+}
+
+// InitScript returns the location of a service's OpenRC init script
+func InitScript(name string) string {
+	return Resolve(filepath.Join(initDir, name))
+}
+
+// ConfFile returns the location of a service's OpenRC configuration file
+func ConfFile(name string) string {
+	return Resolve(filepath.Join(confDir, name))
+}
+
+// Runlevel returns the location of a runlevel directory
+func Runlevel(runlevel string) string {
+	return Resolve(filepath.Join(RunlevelsDir, runlevel))
+}
+
+// OpenRCState returns a location inside OpenRC's state directory, e.g.
+// OpenRCState("started", "nginx")
+func OpenRCState(elem ...string) string {
+	return Resolve(filepath.Join(append([]string{OpenRCRunDir}, elem...)...))
+}
+
+// StateFile returns the location of a file in the tool's state directory
+func StateFile(name string) string {
+	return Resolve(filepath.Join(StateDir, name))
+}
+
+// UnitDirs returns the systemd unit file locations, in order of precedence
+func UnitDirs() []string {
+	dirs := make([]string, len(unitDirs))
+	for i, dir := range unitDirs {
+		dirs[i] = Resolve(dir)
+	}
+	return dirs
+}
@@ -0,0 +1,161 @@
+package proc
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cgroupMount is where the cgroup filesystem is mounted
+const cgroupMount = "/sys/fs/cgroup"
+
+// CGroupOf returns the cgroup path of a process, preferring the unified
+// (v2) hierarchy and falling back to OpenRC's named v1 hierarchy
+func CGroupOf(pid int) (string, error) {
+	f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var named string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Each line is hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			return parts[2], nil
+		}
+		if parts[1] == "name=openrc" {
+			named = parts[2]
+		}
+	}
+
+	return named, scanner.Err()
+}
+
+// cgroupDir resolves a cgroup path to its directory under the cgroup mount
+func cgroupDir(cgroup string) string {
+	candidates := []string{
+		filepath.Join(cgroupMount, cgroup),
+		filepath.Join(cgroupMount, "unified", cgroup),
+		filepath.Join(cgroupMount, "openrc", cgroup),
+	}
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "cgroup.procs")); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
+
+// CGroupProcs returns the pids that are members of a cgroup, in order
+func CGroupProcs(cgroup string) ([]int, error) {
+	dir := cgroupDir(cgroup)
+	if dir == "" {
+		return nil, os.ErrNotExist
+	}
+
+	var pids []int
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "cgroup.procs" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, field := range strings.Fields(string(data)) {
+			if pid, err := strconv.Atoi(field); err == nil {
+				pids = append(pids, pid)
+			}
+		}
+		return nil
+	})
+	sort.Ints(pids)
+
+	return pids, err
+}
+
+// CGroupMemory returns the current memory usage of a cgroup in bytes
+func CGroupMemory(cgroup string) (uint64, bool) {
+	return readCGroupUint(cgroup, "memory.current", "memory.usage_in_bytes")
+}
+
+// CGroupTasks returns the number of tasks in a cgroup
+func CGroupTasks(cgroup string) (uint64, bool) {
+	return readCGroupUint(cgroup, "pids.current")
+}
+
+// readCGroupUint reads the first available numeric control file of a cgroup
+func readCGroupUint(cgroup string, files ...string) (uint64, bool) {
+	dir := cgroupDir(cgroup)
+	if dir == "" {
+		return 0, false
+	}
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			return value, true
+		}
+	}
+
+	return 0, false
+}
+
+// CGroupCPUUsage returns the CPU time used by the processes of a cgroup,
+// from cpu.stat on the unified hierarchy or cpuacct.usage on v1
+func CGroupCPUUsage(cgroup string) (time.Duration, bool) {
+	dir := cgroupDir(cgroup)
+	if dir == "" {
+		return 0, false
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "cpu.stat")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "usage_usec "); ok {
+				if usec, err := strconv.ParseUint(value, 10, 64); err == nil {
+					return time.Duration(usec) * time.Microsecond, true
+				}
+			}
+		}
+	}
+
+	if nsec, ok := readCGroupUint(cgroup, "cpuacct.usage"); ok {
+		return time.Duration(nsec), true
+	}
+	return 0, false
+}
+
+// CGroupLimit returns the value of a limit control file of a cgroup, such
+// as memory.max or pids.max. Unlimited is reported as math.MaxUint64, as
+// systemd does; ok is false if the file cannot be read.
+func CGroupLimit(cgroup, file string) (limit uint64, ok bool) {
+	dir := cgroupDir(cgroup)
+	if dir == "" {
+		return 0, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return math.MaxUint64, true
+	}
+	limit, err = strconv.ParseUint(value, 10, 64)
+	return limit, err == nil
+}
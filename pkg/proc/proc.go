@@ -0,0 +1,235 @@
+package proc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// clockTicks is the kernel USER_HZ used for /proc/<pid>/stat times.
+// It is 100 on every architecture Alpine ships.
+const clockTicks = 100
+
+// Process describes a running process as seen through /proc
+type Process struct {
+	PID       int
+	PPID      int
+	PGID      int
+	Comm      string
+	Cmdline   string
+	Threads   int
+	RSS       uint64
+	StartTime time.Time
+	// CPUTime is the user and system time the process has used
+	CPUTime time.Duration
+}
+
+// ReadPidFile reads a pid from a pidfile
+func ReadPidFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid in %s", path)
+	}
+
+	return pid, nil
+}
+
+// Alive reports whether a process with the given pid exists and has not
+// exited, waiting only to be reaped
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return false
+	}
+	return !zombie(pid)
+}
+
+// zombie reports whether a process has exited but has not been reaped
+func zombie(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	s := string(stat)
+	end := strings.LastIndexByte(s, ')')
+	return end >= 0 && strings.HasPrefix(strings.TrimSpace(s[end+1:]), "Z")
+}
+
+// Get reads information about a single process
+func Get(pid int) (*Process, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Process{PID: pid}
+
+	// The comm field is wrapped in parentheses and may contain spaces,
+	// so split around the last closing parenthesis
+	s := string(stat)
+	open := strings.IndexByte(s, '(')
+	end := strings.LastIndexByte(s, ')')
+	if open < 0 || end < open {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	p.Comm = s[open+1 : end]
+
+	// Fields after comm start at field 3 (state)
+	fields := strings.Fields(s[end+1:])
+	if len(fields) > 2 {
+		p.PPID, _ = strconv.Atoi(fields[1])
+		p.PGID, _ = strconv.Atoi(fields[2])
+	}
+	if len(fields) > 12 {
+		utime, _ := strconv.ParseUint(fields[11], 10, 64)
+		stime, _ := strconv.ParseUint(fields[12], 10, 64)
+		p.CPUTime = time.Duration(utime+stime) * time.Second / clockTicks
+	}
+	if len(fields) > 19 {
+		if ticks, err := strconv.ParseUint(fields[19], 10, 64); err == nil {
+			if boot, err := BootTime(); err == nil {
+				p.StartTime = boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+			}
+		}
+	}
+
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		p.Cmdline = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+
+	if status, err := os.Open(filepath.Join(dir, "status")); err == nil {
+		scanner := bufio.NewScanner(status)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch key {
+			case "Threads":
+				p.Threads, _ = strconv.Atoi(value)
+			case "VmRSS":
+				kb, _ := strconv.ParseUint(strings.TrimSuffix(value, " kB"), 10, 64)
+				p.RSS = kb * 1024
+			}
+		}
+		status.Close()
+	}
+
+	return p, nil
+}
+
+// List returns all processes currently visible in /proc
+func List() ([]*Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []*Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Processes may exit while we walk the directory
+		if p, err := Get(pid); err == nil {
+			processes = append(processes, p)
+		}
+	}
+
+	return processes, nil
+}
+
+// Descendants returns the process with the given pid followed by all of its
+// children, grandchildren and so on, ordered by pid
+func Descendants(pid int) ([]*Process, error) {
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]*Process)
+	var root *Process
+	for _, p := range all {
+		children[p.PPID] = append(children[p.PPID], p)
+		if p.PID == pid {
+			root = p
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("process %d not found", pid)
+	}
+
+	result := []*Process{root}
+	queue := []int{pid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			result = append(result, child)
+			queue = append(queue, child.PID)
+		}
+	}
+
+	sort.Slice(result[1:], func(i, j int) bool {
+		return result[i+1].PID < result[j+1].PID
+	})
+
+	return result, nil
+}
+
+// Group returns the processes of a process group, ordered by pid
+func Group(pgid int) ([]*Process, error) {
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*Process
+	for _, p := range all {
+		if p.PGID == pgid {
+			members = append(members, p)
+		}
+	}
+
+	return members, nil
+}
+
+// BootTime returns the time the system booted, from /proc/stat
+func BootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "btime ") {
+			secs, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "btime ")), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("btime not found in /proc/stat")
+}
@@ -0,0 +1,121 @@
+package proc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// signalNames maps the names of the standard Linux signals, without the SIG
+// prefix, to their numbers
+var signalNames = map[string]syscall.Signal{
+	"HUP":    syscall.SIGHUP,
+	"INT":    syscall.SIGINT,
+	"QUIT":   syscall.SIGQUIT,
+	"ILL":    syscall.SIGILL,
+	"TRAP":   syscall.SIGTRAP,
+	"ABRT":   syscall.SIGABRT,
+	"IOT":    syscall.SIGIOT,
+	"BUS":    syscall.SIGBUS,
+	"FPE":    syscall.SIGFPE,
+	"KILL":   syscall.SIGKILL,
+	"USR1":   syscall.SIGUSR1,
+	"SEGV":   syscall.SIGSEGV,
+	"USR2":   syscall.SIGUSR2,
+	"PIPE":   syscall.SIGPIPE,
+	"ALRM":   syscall.SIGALRM,
+	"TERM":   syscall.SIGTERM,
+	"STKFLT": syscall.SIGSTKFLT,
+	"CHLD":   syscall.SIGCHLD,
+	"CONT":   syscall.SIGCONT,
+	"STOP":   syscall.SIGSTOP,
+	"TSTP":   syscall.SIGTSTP,
+	"TTIN":   syscall.SIGTTIN,
+	"TTOU":   syscall.SIGTTOU,
+	"URG":    syscall.SIGURG,
+	"XCPU":   syscall.SIGXCPU,
+	"XFSZ":   syscall.SIGXFSZ,
+	"VTALRM": syscall.SIGVTALRM,
+	"PROF":   syscall.SIGPROF,
+	"WINCH":  syscall.SIGWINCH,
+	"IO":     syscall.SIGIO,
+	"POLL":   syscall.SIGPOLL,
+	"PWR":    syscall.SIGPWR,
+	"SYS":    syscall.SIGSYS,
+}
+
+// Real-time signals available to applications. The C library reserves the
+// first ones for its threading implementation: musl, which Alpine's daemons
+// are linked against, keeps 32 to 34, so SIGRTMIN is 35 there (glibc's is
+// 34).
+const (
+	sigRTMin = 35
+	sigRTMax = 64
+)
+
+// ParseSignal parses a signal given by name, with or without the SIG prefix
+// and in any case ("SIGUSR1", "usr1"), as RTMIN+n or RTMAX-n, or by number
+func ParseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 || n > sigRTMax {
+			return 0, fmt.Errorf("invalid signal number %d", n)
+		}
+		return syscall.Signal(n), nil
+	}
+
+	name := strings.TrimPrefix(strings.ToUpper(s), "SIG")
+	if sig, ok := signalNames[name]; ok {
+		return sig, nil
+	}
+
+	if n, ok := realtimeSignal(name); ok {
+		return syscall.Signal(n), nil
+	}
+
+	return 0, fmt.Errorf("unknown signal %q", s)
+}
+
+// realtimeSignal parses RTMIN, RTMIN+n, RTMAX and RTMAX-n
+func realtimeSignal(name string) (int, bool) {
+	n := 0
+	switch {
+	case name == "RTMIN":
+		n = sigRTMin
+	case name == "RTMAX":
+		n = sigRTMax
+	case strings.HasPrefix(name, "RTMIN+"):
+		offset, err := strconv.Atoi(name[len("RTMIN+"):])
+		if err != nil {
+			return 0, false
+		}
+		n = sigRTMin + offset
+	case strings.HasPrefix(name, "RTMAX-"):
+		offset, err := strconv.Atoi(name[len("RTMAX-"):])
+		if err != nil {
+			return 0, false
+		}
+		n = sigRTMax - offset
+	}
+	return n, n >= sigRTMin && n <= sigRTMax
+}
+
+// SignalName returns the name of a signal as systemd prints it, e.g. "SIGTERM"
+func SignalName(sig syscall.Signal) string {
+	best := ""
+	for name, n := range signalNames {
+		// IOT and POLL are aliases of ABRT and IO
+		if n == sig && name != "IOT" && name != "POLL" {
+			best = name
+		}
+	}
+	switch {
+	case best != "":
+		return "SIG" + best
+	case int(sig) == sigRTMin:
+		return "SIGRTMIN"
+	case int(sig) > sigRTMin && int(sig) <= sigRTMax:
+		return "SIGRTMIN+" + strconv.Itoa(int(sig)-sigRTMin)
+	}
+	return strconv.Itoa(int(sig))
+}
@@ -0,0 +1,52 @@
+package proc
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  syscall.Signal
+	}{
+		{"SIGTERM", syscall.SIGTERM},
+		{"usr1", syscall.SIGUSR1},
+		{"9", syscall.SIGKILL},
+		{"RTMIN", 35},
+		{"SIGRTMIN+3", 38},
+		{"rtmax", 64},
+		{"RTMAX-2", 62},
+	}
+	for _, tt := range tests {
+		got, err := ParseSignal(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSignal(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"0", "65", "FOO", "RTMIN+30", "RTMAX-30", "RTMIN+x"} {
+		if got, err := ParseSignal(input); err == nil {
+			t.Errorf("ParseSignal(%q) = %d, want an error", input, got)
+		}
+	}
+}
+
+func TestSignalName(t *testing.T) {
+	tests := []struct {
+		sig  syscall.Signal
+		want string
+	}{
+		{syscall.SIGTERM, "SIGTERM"},
+		{syscall.SIGABRT, "SIGABRT"},
+		{syscall.SIGIO, "SIGIO"},
+		{34, "34"},
+		{35, "SIGRTMIN"},
+		{40, "SIGRTMIN+5"},
+	}
+	for _, tt := range tests {
+		if got := SignalName(tt.sig); got != tt.want {
+			t.Errorf("SignalName(%d) = %q, want %q", tt.sig, got, tt.want)
+		}
+	}
+}
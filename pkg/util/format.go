@@ -0,0 +1,98 @@
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatTimestamp formats a time the way systemd prints timestamps
+func FormatTimestamp(t time.Time) string {
+	return t.Format("Mon 2006-01-02 15:04:05 MST")
+}
+
+// FormatTimestampRelative formats the distance between t and now the way
+// systemd does in status output, e.g. "2h 3min ago"
+func FormatTimestampRelative(t time.Time) string {
+	d := time.Since(t)
+	if d < 0 {
+		return "in the future"
+	}
+
+	const (
+		day   = 24 * time.Hour
+		week  = 7 * day
+		month = 2629800 * time.Second
+		year  = 31557600 * time.Second
+	)
+
+	switch {
+	case d >= year:
+		years := d / year
+		months := (d % year) / month
+		if months > 0 {
+			return fmt.Sprintf("%d %s %d %s ago", years, plural(int64(years), "year", "years"), months, plural(int64(months), "month", "months"))
+		}
+		return fmt.Sprintf("%d %s ago", years, plural(int64(years), "year", "years"))
+	case d >= month:
+		months := d / month
+		days := (d % month) / day
+		if days > 0 {
+			return fmt.Sprintf("%d %s %d %s ago", months, plural(int64(months), "month", "months"), days, plural(int64(days), "day", "days"))
+		}
+		return fmt.Sprintf("%d %s ago", months, plural(int64(months), "month", "months"))
+	case d >= week:
+		weeks := d / week
+		days := (d % week) / day
+		if days > 0 {
+			return fmt.Sprintf("%d %s %d %s ago", weeks, plural(int64(weeks), "week", "weeks"), days, plural(int64(days), "day", "days"))
+		}
+		return fmt.Sprintf("%d %s ago", weeks, plural(int64(weeks), "week", "weeks"))
+	case d >= 2*day:
+		return fmt.Sprintf("%d days ago", d/day)
+	case d >= 25*time.Hour:
+		return fmt.Sprintf("1 day %dh ago", (d-day)/time.Hour)
+	case d >= 6*time.Hour:
+		return fmt.Sprintf("%dh ago", d/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dmin ago", d/time.Hour, (d%time.Hour)/time.Minute)
+	case d >= 5*time.Minute:
+		return fmt.Sprintf("%dmin ago", d/time.Minute)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dmin %ds ago", d/time.Minute, (d%time.Minute)/time.Second)
+	case d >= time.Second:
+		return fmt.Sprintf("%ds ago", d/time.Second)
+	default:
+		return fmt.Sprintf("%dms ago", d/time.Millisecond)
+	}
+}
+
+// FormatBytes formats a byte count using systemd's binary suffixes, e.g. "4.5M"
+func FormatBytes(b uint64) string {
+	suffixes := []struct {
+		suffix string
+		factor uint64
+	}{
+		{"E", 1 << 60},
+		{"P", 1 << 50},
+		{"T", 1 << 40},
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+	}
+
+	for _, s := range suffixes {
+		if b >= s.factor {
+			return fmt.Sprintf("%d.%d%s", b/s.factor, (b%s.factor)*10/s.factor, s.suffix)
+		}
+	}
+
+	return fmt.Sprintf("%dB", b)
+}
+
+// plural picks the singular or plural form of a word
+func plural(n int64, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}